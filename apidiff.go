@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Arman92/go-tl-parser/generator"
+)
+
+// apiDiffDocument is the output of 'apidiff -json'
+type apiDiffDocument struct {
+	Old      string                 `json:"old"`
+	New      string                 `json:"new"`
+	Breaking bool                   `json:"breaking"`
+	Changes  []*generator.APIChange `json:"changes"`
+}
+
+// runAPIDiff implements 'apidiff [-json] [-package name] [-naming file] old new', printing the changes between the Go APIs
+// generated for two schemas and exiting with status 1 if any of them is breaking
+func runAPIDiff(args []string) {
+	flags := flag.NewFlagSet("apidiff", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print the changes as JSON")
+	packageName := flags.String("package", "tdlib", "package name of the generated types")
+	namingFile := flags.String("naming", "", "JSON file of naming rules, as for generating code")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s apidiff [-json] [-package name] [-naming file] old new\n\nold and new are .tl files, directories, URLs or - for stdin\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	oldSchema, oldName := parseSchema(flags.Arg(0))
+	newSchema, newName := parseSchema(flags.Arg(1))
+	options := generator.Options{PackageName: *packageName, Naming: loadNaming(*namingFile)}
+	changes := generator.CompareAPI(generator.BuildAPI(oldSchema, options), generator.BuildAPI(newSchema, options))
+	breaking := generator.HasBreaking(changes)
+
+	if *jsonOutput {
+		if changes == nil {
+			changes = []*generator.APIChange{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(&apiDiffDocument{Old: oldName, New: newName, Breaking: breaking, Changes: changes})
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+
+	if breaking {
+		os.Exit(1)
+	}
+}
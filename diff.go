@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// diffDocument is the output of 'diff -json'
+type diffDocument struct {
+	Old     string             `json:"old"`
+	New     string             `json:"new"`
+	Changes []*tlparser.Change `json:"changes"`
+}
+
+// runDiff implements 'diff [-json] old new', printing the changes between two schemas
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print the changes as JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s diff [-json] old new\n\nold and new are .tl files, directories, URLs or - for stdin\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	oldSchema, oldName := parseSchema(flags.Arg(0))
+	newSchema, newName := parseSchema(flags.Arg(1))
+	changes := tlparser.Diff(oldSchema, newSchema)
+
+	if *jsonOutput {
+		if changes == nil {
+			changes = []*tlparser.Change{}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(&diffDocument{Old: oldName, New: newName, Changes: changes}); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// runFormat implements 'fmt [-w] [schema]', printing the schema as canonical TL
+func runFormat(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result to the schema file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s fmt [-w] [schema]\n\nschema is a .tl file, a directory, a URL or - for stdin (the default)\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	location := "-"
+	if flags.NArg() > 0 {
+		location = flags.Arg(0)
+	}
+
+	schema, name := parseSchema(location)
+	formatted := tlparser.Format(schema)
+
+	// make sure nothing is lost in the process
+	reparsed, err := tlparser.ParseInputSchemaFile(name, bytes.NewReader(formatted))
+	if err != nil {
+		log.Fatalf("formatted schema does not parse: %s", err)
+	}
+	if !sameSchema(schema, reparsed) {
+		log.Fatalf("formatting %s would change its meaning, please report it as a bug", name)
+	}
+
+	if !*write {
+		os.Stdout.Write(formatted)
+		return
+	}
+
+	if location == "-" || strings.Contains(location, "://") {
+		log.Fatalf("-w needs a local schema file")
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(name, formatted, info.Mode()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseSchema opens and parses the schema found at location, exiting on errors.
+// It returns the name the schema was resolved to.
+func parseSchema(location string) (*tlparser.TlSchema, string) {
+	source, err := openSchema(location)
+	if err != nil {
+		log.Fatalf("failed to open schema: %s", err)
+	}
+	defer source.Close()
+
+	schema, err := tlparser.ParseInputSchemaFile(source.Name, source)
+	if errs, ok := err.(tlparser.ParseErrors); ok {
+		for _, parseErr := range errs {
+			log.Print(parseErr)
+		}
+		log.Fatalf("schema parse error: %d errors found", len(errs))
+	}
+	if err != nil {
+		log.Fatalf("schema parse error: %s", err)
+	}
+	for _, diagnostic := range schema.Diagnostics {
+		log.Print(diagnostic)
+	}
+
+	return schema, source.Name
+}
+
+// sameSchema reports whether a and b declare the same things, wherever they were declared
+func sameSchema(a, b *tlparser.TlSchema) bool {
+	encode := func(schema *tlparser.TlSchema) []byte {
+		withoutDiagnostics := *schema
+		withoutDiagnostics.Diagnostics = nil
+		data, _ := json.Marshal(withoutDiagnostics)
+		return data
+	}
+
+	return bytes.Equal(encode(a), encode(b))
+}
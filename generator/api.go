@@ -0,0 +1,313 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Arman92/go-tl-parser/naming"
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// API is the Go API generated for a schema, as its callers see it
+type API struct {
+	Structs    map[string]*APIStruct `json:"structs"`    // by struct name
+	Interfaces map[string]bool       `json:"interfaces"` // names of the interfaces of abstract classes
+	Enums      map[string][]string   `json:"enums"`      // enum type name, such as MessageContentEnum, to its constants
+	Methods    map[string]*APIFunc   `json:"methods"`    // methods of Client, by name
+}
+
+// APIStruct is the struct generated for a class, and its NewX constructor
+type APIStruct struct {
+	Name        string            `json:"name"`
+	Fields      map[string]string `json:"fields"`              // field name to Go type
+	Interface   string            `json:"interface,omitempty"` // the interface implemented through GetXEnum, if any
+	Constructor *APIFunc          `json:"constructor"`
+}
+
+// APIFunc is the signature of a generated function or method
+type APIFunc struct {
+	Name    string     `json:"name"`
+	Params  []APIParam `json:"params"`
+	Results []string   `json:"results"`
+}
+
+// APIParam is a parameter of an APIFunc
+type APIParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Signature returns the Go signature of the function, such as 'func(chatID int64) (*tdlib.Chat, error)'
+func (fn *APIFunc) Signature() string {
+	params := make([]string, 0, len(fn.Params))
+	for _, param := range fn.Params {
+		params = append(params, param.Name+" "+param.Type)
+	}
+
+	results := strings.Join(fn.Results, ", ")
+	if len(fn.Results) > 1 {
+		results = "(" + results + ")"
+	}
+
+	return fmt.Sprintf("func(%s) %s", strings.Join(params, ", "), results)
+}
+
+// BuildAPI returns the Go API the generator produces for schema with options, of which only
+// PackageName, the name of the types package as used by the methods, Naming and Context matter
+func BuildAPI(schema *tlparser.TlSchema, options Options) *API {
+	model := buildModel(schema, options.names(), options)
+
+	api := &API{
+		Structs:    map[string]*APIStruct{},
+		Interfaces: map[string]bool{},
+		Enums:      map[string][]string{},
+		Methods:    map[string]*APIFunc{},
+	}
+
+	for _, modelInterface := range model.Interfaces {
+		api.Interfaces[modelInterface.GoName] = true
+
+		api.Enums[modelInterface.EnumName] = []string{}
+		for _, class := range modelInterface.Classes {
+			api.Enums[modelInterface.EnumName] = append(api.Enums[modelInterface.EnumName], class.GoName+"Type")
+		}
+	}
+
+	for _, class := range model.Classes {
+		apiStruct := &APIStruct{
+			Name:        class.GoName,
+			Fields:      map[string]string{},
+			Constructor: &APIFunc{Name: "New" + class.GoName, Results: []string{"*" + class.GoName}},
+		}
+
+		if class.Interface != nil {
+			apiStruct.Interface = class.Interface.GoName
+		}
+
+		for _, field := range class.Fields {
+			apiStruct.Fields[field.GoName] = field.GoType
+			apiStruct.Constructor.Params = append(apiStruct.Constructor.Params, APIParam{Name: field.ParamName, Type: field.GoType})
+		}
+
+		api.Structs[class.GoName] = apiStruct
+	}
+
+	for _, function := range model.Functions {
+		result := function.Result
+		if function.ResultPointer {
+			result = "*" + result
+		}
+		method := &APIFunc{
+			Name:    function.GoName,
+			Results: []string{result, "error"},
+		}
+
+		if function.Context {
+			method.Params = append(method.Params, APIParam{Name: "ctx", Type: "context.Context"})
+		}
+		for _, param := range function.Params {
+			method.Params = append(method.Params, APIParam{Name: param.ParamName, Type: param.GoType})
+		}
+
+		api.Methods[method.Name] = method
+
+		request := &APIStruct{
+			Name:        function.RequestName,
+			Fields:      map[string]string{},
+			Constructor: &APIFunc{Name: "New" + function.RequestName, Results: []string{"*" + function.RequestName}},
+		}
+		for _, param := range function.Params {
+			request.Fields[param.GoName] = param.RequestType
+			request.Constructor.Params = append(request.Constructor.Params, APIParam{Name: param.ParamName, Type: param.RequestType})
+		}
+
+		api.Structs[request.Name] = request
+	}
+
+	return api
+}
+
+// structName returns the name of the struct generated for a class
+func structName(className string, names *naming.Namer) string {
+	return names.Type(className)
+}
+
+// fieldName returns the name of the struct field generated for a property of a class
+func fieldName(className, propName string, names *naming.Namer) string {
+	return names.Field(className, propName)
+}
+
+// methodName returns the name of the Client method generated for a function
+func methodName(functionName string, names *naming.Namer) string {
+	return names.Method(functionName)
+}
+
+// Severity of an APIChange
+const (
+	Compatible = "compatible"
+	Breaking   = "breaking"
+)
+
+// APIChange is a difference between two versions of the generated Go API
+type APIChange struct {
+	Severity string `json:"severity"` // Compatible or Breaking
+	Symbol   string `json:"symbol"`   // e.g. 'Message.ChatID', 'NewMessage', 'Client.GetChat' or 'MessageContentEnum'
+	Message  string `json:"message"`
+}
+
+func (change *APIChange) String() string {
+	return fmt.Sprintf("%s: %s: %s", change.Severity, change.Symbol, change.Message)
+}
+
+// CompareAPI returns the changes between two versions of the generated API, sorted by symbol.
+// Changes that can stop callers' code from compiling, or change its meaning, are Breaking:
+// removed symbols, changed field types, structs no longer implementing an interface, and any change to the parameter or result types
+// of constructors and methods. Added symbols and renamed parameters are Compatible.
+func CompareAPI(old, new *API) []*APIChange {
+	var changes []*APIChange
+	add := func(severity, symbol, format string, args ...interface{}) {
+		changes = append(changes, &APIChange{Severity: severity, Symbol: symbol, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for name, oldStruct := range old.Structs {
+		newStruct, ok := new.Structs[name]
+		if !ok {
+			add(Breaking, name, "struct removed")
+			add(Breaking, oldStruct.Constructor.Name, "constructor removed")
+			continue
+		}
+
+		for field, oldType := range oldStruct.Fields {
+			newType, ok := newStruct.Fields[field]
+			switch {
+			case !ok:
+				add(Breaking, name+"."+field, "field removed")
+			case oldType != newType:
+				add(Breaking, name+"."+field, "field type changed from %s to %s", oldType, newType)
+			}
+		}
+		for field, newType := range newStruct.Fields {
+			if _, ok := oldStruct.Fields[field]; !ok {
+				add(Compatible, name+"."+field, "field added, of type %s", newType)
+			}
+		}
+
+		if oldStruct.Interface != "" && oldStruct.Interface != newStruct.Interface {
+			add(Breaking, name, "no longer implements %s", oldStruct.Interface)
+		}
+		if newStruct.Interface != "" && oldStruct.Interface != newStruct.Interface {
+			add(Compatible, name, "now implements %s", newStruct.Interface)
+		}
+
+		compareFuncs(oldStruct.Constructor, newStruct.Constructor, oldStruct.Constructor.Name, add)
+	}
+	for name, newStruct := range new.Structs {
+		if _, ok := old.Structs[name]; !ok {
+			add(Compatible, name, "struct added")
+			add(Compatible, newStruct.Constructor.Name, "constructor added")
+		}
+	}
+
+	for name := range old.Interfaces {
+		if !new.Interfaces[name] {
+			add(Breaking, name, "interface removed")
+		}
+	}
+	for name := range new.Interfaces {
+		if !old.Interfaces[name] {
+			add(Compatible, name, "interface added")
+		}
+	}
+
+	for name, oldConstants := range old.Enums {
+		newConstants, ok := new.Enums[name]
+		if !ok {
+			add(Breaking, name, "enum type removed")
+			continue
+		}
+
+		for _, constant := range difference(oldConstants, newConstants) {
+			add(Breaking, name+"."+constant, "enum constant removed")
+		}
+		for _, constant := range difference(newConstants, oldConstants) {
+			add(Compatible, name+"."+constant, "enum constant added")
+		}
+	}
+	for name := range new.Enums {
+		if _, ok := old.Enums[name]; !ok {
+			add(Compatible, name, "enum type added")
+		}
+	}
+
+	for name, oldMethod := range old.Methods {
+		newMethod, ok := new.Methods[name]
+		if !ok {
+			add(Breaking, "Client."+name, "method removed")
+			continue
+		}
+
+		compareFuncs(oldMethod, newMethod, "Client."+name, add)
+	}
+	for name := range new.Methods {
+		if _, ok := old.Methods[name]; !ok {
+			add(Compatible, "Client."+name, "method added")
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Symbol != changes[j].Symbol {
+			return changes[i].Symbol < changes[j].Symbol
+		}
+		return changes[i].Message < changes[j].Message
+	})
+
+	return changes
+}
+
+// compareFuncs reports the changes of the signature of a function, through add
+func compareFuncs(old, new *APIFunc, symbol string, add func(severity, symbol, format string, args ...interface{})) {
+	if funcTypes(old) != funcTypes(new) {
+		add(Breaking, symbol, "signature changed from %s to %s", old.Signature(), new.Signature())
+	} else if old.Signature() != new.Signature() {
+		add(Compatible, symbol, "parameters renamed, now %s", new.Signature())
+	}
+}
+
+// funcTypes returns the signature of fn without the parameter names, which do not matter to callers
+func funcTypes(fn *APIFunc) string {
+	types := make([]string, 0, len(fn.Params))
+	for _, param := range fn.Params {
+		types = append(types, param.Type)
+	}
+
+	return strings.Join(types, ", ") + " -> " + strings.Join(fn.Results, ", ")
+}
+
+// difference returns the items of a that are not in b
+func difference(a, b []string) []string {
+	inB := map[string]bool{}
+	for _, item := range b {
+		inB[item] = true
+	}
+
+	var items []string
+	for _, item := range a {
+		if !inB[item] {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// HasBreaking reports whether any of changes is Breaking
+func HasBreaking(changes []*APIChange) bool {
+	for _, change := range changes {
+		if change.Severity == Breaking {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,218 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const apiTestSchema = `
+//@class Content @description A content
+
+contentText text:string = Content;
+contentPhoto id:int32 = Content;
+
+chat id:int53 title:string = Chat;
+
+---functions---
+
+getChat chat_id:int53 = Chat;
+getContent chat_id:int53 = Content;
+`
+
+func TestBuildAPI(t *testing.T) {
+	api := BuildAPI(parseTestSchema(t, apiTestSchema), Options{PackageName: "tdlib", Context: true})
+
+	chat := api.Structs["Chat"]
+	if chat == nil || !reflect.DeepEqual(chat.Fields, map[string]string{"ID": "int64", "Title": "string"}) {
+		t.Fatalf("got struct %+v", chat)
+	}
+	if signature := chat.Constructor.Signature(); signature != "func(id int64, title string) *Chat" {
+		t.Errorf("NewChat: got %s", signature)
+	}
+	if api.Structs["ContentText"].Interface != "Content" || !api.Interfaces["Content"] {
+		t.Errorf("got ContentText %+v, interfaces %v", api.Structs["ContentText"], api.Interfaces)
+	}
+	if constants := api.Enums["ContentEnum"]; !reflect.DeepEqual(constants, []string{"ContentTextType", "ContentPhotoType"}) {
+		t.Errorf("got enum %v", constants)
+	}
+
+	for name, want := range map[string]string{
+		"GetChat":    "func(ctx context.Context, chatID int64) (*tdlib.Chat, error)",
+		"GetContent": "func(ctx context.Context, chatID int64) (tdlib.Content, error)",
+	} {
+		if method := api.Methods[name]; method == nil || method.Signature() != want {
+			t.Errorf("%s: got %+v, want %s", name, method, want)
+		}
+	}
+	if request := api.Structs["GetChatRequest"]; request == nil || request.Fields["ChatID"] != "int64" {
+		t.Errorf("got request %+v", request)
+	}
+}
+
+func TestCompareAPI(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string // replaced in apiTestSchema
+		changes  []string
+		breaking bool
+	}{
+		{name: "unchanged"},
+		{
+			name: "field added",
+			old:  "chat id:int53 title:string = Chat;", new: "chat id:int53 title:string photo:string = Chat;",
+			changes: []string{
+				"compatible: Chat.Photo: field added, of type string",
+				"breaking: NewChat: signature changed from func(id int64, title string) *Chat to func(id int64, title string, photo string) *Chat",
+			},
+			breaking: true,
+		},
+		{
+			name: "field removed",
+			old:  "chat id:int53 title:string = Chat;", new: "chat id:int53 = Chat;",
+			changes: []string{
+				"breaking: Chat.Title: field removed",
+				"breaking: NewChat: signature changed from func(id int64, title string) *Chat to func(id int64) *Chat",
+			},
+			breaking: true,
+		},
+		{
+			name: "field type changed",
+			old:  "chat id:int53 title:string = Chat;", new: "chat id:int32 title:string = Chat;",
+			changes: []string{
+				"breaking: Chat.ID: field type changed from int64 to int32",
+				"breaking: NewChat: signature changed from func(id int64, title string) *Chat to func(id int32, title string) *Chat",
+			},
+			breaking: true,
+		},
+		{
+			name: "field renamed",
+			old:  "chat id:int53 title:string = Chat;", new: "chat id:int53 name:string = Chat;",
+			changes: []string{
+				"breaking: Chat.Title: field removed",
+				"compatible: Chat.Name: field added, of type string",
+				"compatible: NewChat: parameters renamed, now func(id int64, name string) *Chat",
+			},
+			breaking: true,
+		},
+		{
+			name: "class added",
+			old:  "contentPhoto id:int32 = Content;", new: "contentPhoto id:int32 = Content;\ncontentVideo id:int32 = Content;",
+			changes: []string{
+				"compatible: ContentEnum.ContentVideoType: enum constant added",
+				"compatible: ContentVideo: struct added",
+				"compatible: NewContentVideo: constructor added",
+			},
+		},
+		{
+			name: "class removed",
+			old:  "contentPhoto id:int32 = Content;", new: "",
+			changes: []string{
+				"breaking: ContentEnum.ContentPhotoType: enum constant removed",
+				"breaking: ContentPhoto: struct removed",
+				"breaking: NewContentPhoto: constructor removed",
+			},
+			breaking: true,
+		},
+		{
+			name: "class moved out of its interface",
+			old:  "contentPhoto id:int32 = Content;", new: "contentPhoto id:int32 = ContentPhoto;",
+			changes: []string{
+				"breaking: ContentEnum.ContentPhotoType: enum constant removed",
+				"breaking: ContentPhoto: no longer implements Content",
+			},
+			breaking: true,
+		},
+		{
+			name: "method added",
+			old:  "getChat chat_id:int53 = Chat;", new: "getChat chat_id:int53 = Chat;\ngetChats = Chat;",
+			changes: []string{
+				"compatible: Client.GetChats: method added",
+				"compatible: GetChatsRequest: struct added",
+				"compatible: NewGetChatsRequest: constructor added",
+			},
+		},
+		{
+			name: "method removed",
+			old:  "getChat chat_id:int53 = Chat;", new: "",
+			changes: []string{
+				"breaking: Client.GetChat: method removed",
+				"breaking: GetChatRequest: struct removed",
+				"breaking: NewGetChatRequest: constructor removed",
+			},
+			breaking: true,
+		},
+		{
+			name: "method result changed",
+			old:  "getChat chat_id:int53 = Chat;", new: "getChat chat_id:int53 = Content;",
+			changes: []string{
+				"breaking: Client.GetChat: signature changed from func(chatID int64) (*tdlib.Chat, error) to func(chatID int64) (tdlib.Content, error)",
+			},
+			breaking: true,
+		},
+		{
+			name: "method parameter renamed",
+			old:  "getChat chat_id:int53 = Chat;", new: "getChat id:int53 = Chat;",
+			changes: []string{
+				"compatible: Client.GetChat: parameters renamed, now func(id int64) (*tdlib.Chat, error)",
+				"breaking: GetChatRequest.ChatID: field removed",
+				"compatible: GetChatRequest.ID: field added, of type int64",
+				"compatible: NewGetChatRequest: parameters renamed, now func(id int64) *GetChatRequest",
+			},
+			breaking: true,
+		},
+		{
+			name: "interface removed",
+			old:  "//@class Content @description A content\n\ncontentText text:string = Content;\ncontentPhoto id:int32 = Content;",
+			new:  "content text:string = Content;",
+			changes: []string{
+				"breaking: Client.GetContent: signature changed from func(chatID int64) (tdlib.Content, error) to func(chatID int64) (*tdlib.Content, error)",
+				"breaking: Content: interface removed",
+				"compatible: Content: struct added",
+				"breaking: ContentEnum: enum type removed",
+				"breaking: ContentPhoto: struct removed",
+				"breaking: ContentText: struct removed",
+				"compatible: NewContent: constructor added",
+				"breaking: NewContentPhoto: constructor removed",
+				"breaking: NewContentText: constructor removed",
+			},
+			breaking: true,
+		},
+	}
+
+	options := Options{PackageName: "tdlib"}
+	old := BuildAPI(parseTestSchema(t, apiTestSchema), options)
+	for _, test := range tests {
+		new := BuildAPI(parseTestSchema(t, strings.Replace(apiTestSchema, test.old, test.new, 1)), options)
+		changes := CompareAPI(old, new)
+
+		var got []string
+		for _, change := range changes {
+			got = append(got, change.String())
+		}
+		if !sameItems(got, test.changes) {
+			t.Errorf("%s: got changes\n%s\nwant\n%s", test.name, strings.Join(got, "\n"), strings.Join(test.changes, "\n"))
+		}
+		if HasBreaking(changes) != test.breaking {
+			t.Errorf("%s: got breaking %v, want %v", test.name, HasBreaking(changes), test.breaking)
+		}
+	}
+}
+
+// sameItems reports whether a and b have the same items, in any order
+func sameItems(a, b []string) bool {
+	counts := map[string]int{}
+	for _, item := range a {
+		counts[item]++
+	}
+	for _, item := range b {
+		counts[item]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Backend generates code, or anything else, from the Model of a schema and writes the files to sink.
+// The paths are up to the backend, usually within model.Options.TypesOutputDir and MethodsOutputDir.
+type Backend interface {
+	Generate(model *Model, sink Sink) error
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{"go": GoBackend{}}
+)
+
+// RegisterBackend makes backend available by name to LookupBackend, and so to the -backend flag of programs using it.
+// It panics if a backend is already registered with that name.
+func RegisterBackend(name string, backend Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic("generator: backend " + name + " registered twice")
+	}
+	backends[name] = backend
+}
+
+// LookupBackend returns the backend registered with name, "go" being GoBackend
+func LookupBackend(name string) (Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	backend, ok := backends[name]
+	return backend, ok
+}
+
+// BackendNames returns the names of the registered backends, sorted
+func BackendNames() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// GoBackend generates the Go types and the TDLib client methods, with the templates of model.Options
+type GoBackend struct{}
+
+// Generate generates the types package in model.Options.TypesOutputDir and the methods package in MethodsOutputDir
+func (GoBackend) Generate(model *Model, sink Sink) error {
+	if err := checkNames(model); err != nil {
+		return err
+	}
+
+	templates, err := loadTemplates(model.Options.Templates)
+	if err != nil {
+		return err
+	}
+
+	typesOutputDir, methodsOutputDir := model.Options.TypesOutputDir, model.Options.MethodsOutputDir
+	files := newFileSet()
+
+	err = generateCommonFiles(model, templates, typesOutputDir, files)
+	if err != nil {
+		return fmt.Errorf("failed to generate common file: %w", err)
+	}
+
+	err = generateInterfaceAndEnums(model, templates, typesOutputDir, files)
+	if err != nil {
+		return fmt.Errorf("failed to generate interface/enum files: %w", err)
+	}
+
+	err = generateClasses(model, templates, typesOutputDir, files)
+	if err != nil {
+		return fmt.Errorf("failed to generate classes files: %w", err)
+	}
+
+	if model.BinaryCodec {
+		err = generateBinaryCodecs(model, templates, typesOutputDir, files)
+		if err != nil {
+			return fmt.Errorf("failed to generate binary codec files: %w", err)
+		}
+	}
+
+	err = generateRequests(model, templates, typesOutputDir, files)
+	if err != nil {
+		return fmt.Errorf("failed to generate request files: %w", err)
+	}
+
+	err = generateMethods(model, templates, methodsOutputDir, files)
+	if err != nil {
+		return fmt.Errorf("failed to generate method files: %w", err)
+	}
+
+	return files.writeTo(sink)
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// generateBinaryCodecs appends TL binary serialization methods (TLID, EncodeTL and DecodeTL) to the
+// classes generated by generateClasses, and a decodeXTL function to each interface file; generateRequests
+// appends them to requests. Objects are encoded as in MTProto: little-endian integers, padded strings and bytes,
+// 'Vector<T>' and interface (abstract class) fields boxed, 'vector<T>', concrete class fields and '%T' items bare,
+// conditional fields only if their bit of the flags, which are computed, is set.
+func generateBinaryCodecs(model *Model, templates *template.Template, outputDir string, files *fileSet) error {
+	for _, modelInterface := range model.Interfaces {
+		buf := bytes.NewBufferString("\n")
+		if err := executeTemplate(buf, templates, "decoder", modelInterface); err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(outputDir, modelInterface.File)
+		decl, named := interfaceDecls(modelInterface)
+		files.appendNamed(filePath, buf.Bytes(), decl, named)
+	}
+
+	for _, class := range model.Classes {
+		buf := bytes.NewBufferString("\n")
+		if err := executeTemplate(buf, templates, "binary", class); err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(outputDir, class.File)
+		files.append(filePath, buf.Bytes(), class.Decl)
+	}
+
+	return nil
+}
+
+// isBoxedTLType reports whether values of tlType are written with their constructor ID;
+// in TL, type names start with an upper case letter and constructor names with a lower case one.
+func isBoxedTLType(tlType string) bool {
+	first := tlType[:1]
+	return first == strings.ToUpper(first) && first != "%"
+}
+
+// TLValue is a value the encodeValue and decodeValue templates write to or read from TL binary data:
+// a field of a class or request, or the items of its vectors
+type TLValue struct {
+	Expr    string // Go expression of the value, e.g. 'photo.Sizes' or 'item0'
+	Label   string // what error messages call the value, e.g. 'Photo.Sizes item'
+	Type    *Type
+	Depth   int  // number of vectors of Type the value is an item of, it is a vector itself below Type.VectorDepth
+	Pointer bool // whether the value is a pointer to a primitive, as int64 fields of requests are
+
+	// Flags is the flags field of a conditional field, such as 'flags.3?int', only written if its FlagBit is set.
+	// Fields of type true are not written at all, they are the bit.
+	Flags   *TLValue
+	FlagBit int
+	// FlagFields are the conditional fields of a flags field, which gets their bits when written
+	FlagFields []*TLValue
+}
+
+// setTLValues sets the TL of fields, the fields of the struct of a class or request of which receiver is the name,
+// with properties their declarations
+func setTLValues(receiver, structName string, fields []*Field, properties []tlparser.Property) {
+	byName := map[string]*TLValue{}
+	for i, field := range fields {
+		goType := field.GoType
+		if field.RequestType != "" {
+			goType = field.RequestType
+		}
+
+		value := &TLValue{
+			Expr:    receiver + "." + field.GoName,
+			Label:   structName + "." + field.GoName,
+			Type:    field.Type,
+			Pointer: field.Type.Kind == PrimitiveType && strings.HasPrefix(goType, "*"),
+		}
+		if flags, ok := byName[properties[i].FlagField]; ok && properties[i].IsConditional() {
+			value.Flags, value.FlagBit = flags, properties[i].FlagBit
+			flags.FlagFields = append(flags.FlagFields, value)
+		}
+
+		byName[field.Name] = value
+		field.TL = value
+	}
+}
+
+// IsVector reports whether the value is a vector
+func (v *TLValue) IsVector() bool {
+	return v.Depth < v.Type.VectorDepth
+}
+
+// BoxedVector reports whether the value, a vector, is written with its constructor ID
+func (v *TLValue) BoxedVector() bool {
+	return v.Type.BoxedVectors[v.Depth]
+}
+
+// IsInterface reports whether the value is an interface
+func (v *TLValue) IsInterface() bool {
+	return !v.IsVector() && v.Type.Kind == InterfaceType
+}
+
+// IsGeneric reports whether the value is any object, such as the query of invokeWithLayer
+func (v *TLValue) IsGeneric() bool {
+	return !v.IsVector() && v.Type.Kind == GenericType
+}
+
+// IsFlags reports whether the value is a flags field
+func (v *TLValue) IsFlags() bool {
+	return !v.IsVector() && v.Type.Kind == FlagsType
+}
+
+// Nilable reports whether the value, an object, may be nil: interfaces, and classes of fields, which are pointers
+func (v *TLValue) Nilable() bool {
+	return v.IsInterface() || v.IsGeneric() || v.Depth == 0
+}
+
+// Boxed reports whether the value is written with its constructor ID
+func (v *TLValue) Boxed() bool {
+	return !v.Type.Bare && isBoxedTLType(v.Type.Name)
+}
+
+// Unconditional returns the value of a conditional field, written once its flag is checked
+func (v *TLValue) Unconditional() *TLValue {
+	return &TLValue{Expr: v.Expr, Label: v.Label, Type: v.Type, Depth: v.Depth, Pointer: v.Pointer}
+}
+
+// Deref returns the value a Pointer points to
+func (v *TLValue) Deref() *TLValue {
+	return &TLValue{Expr: "*" + v.Expr, Label: v.Label, Type: v.Type, Depth: v.Depth}
+}
+
+// Ref returns the expression of a pointer to the value: fields of classes already are, items of vectors are not
+func (v *TLValue) Ref() string {
+	if v.Depth > 0 {
+		return "&" + v.Expr
+	}
+
+	return v.Expr
+}
+
+// SliceType returns the Go type of the value, a vector
+func (v *TLValue) SliceType() string {
+	return strings.Repeat("[]", v.Type.VectorDepth-v.Depth) + v.Type.GoName
+}
+
+// ItemVar returns the name of the variable items of the value, a vector, are ranged over with
+func (v *TLValue) ItemVar() string {
+	return fmt.Sprintf("item%d", v.Depth)
+}
+
+// IndexVar returns the name of the variable indexes of the value, a vector, are ranged over with
+func (v *TLValue) IndexVar() string {
+	return fmt.Sprintf("i%d", v.Depth)
+}
+
+// Item returns the items of the value, a vector, as encoded: the variable ranging over them
+func (v *TLValue) Item() *TLValue {
+	return &TLValue{Expr: v.ItemVar(), Label: v.Label + " item", Type: v.Type, Depth: v.Depth + 1}
+}
+
+// Element returns the items of the value, a vector, as decoded: the value indexed
+func (v *TLValue) Element() *TLValue {
+	return &TLValue{Expr: v.Expr + "[" + v.IndexVar() + "]", Label: v.Label + " item", Type: v.Type, Depth: v.Depth + 1}
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// Drift is a file whose content on disk differs from what GenerateCode would write
+type Drift struct {
+	Path      string
+	Generated []byte // nil if GenerateCode would remove the file
+	OnDisk    []byte // nil if the file does not exist
+}
+
+// CheckCode generates the code of schema in memory and compares it to the files on disk, without
+// writing anything. It returns the files GenerateCode would change, sorted by path, or the error
+// GenerateCode would fail with.
+func CheckCode(schema *tlparser.TlSchema, options Options) ([]*Drift, error) {
+	files := Files{}
+	if err := Generate(schema, options, files); err != nil {
+		return nil, err
+	}
+
+	paths, stale, err := planWrite(files, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []*Drift
+	for _, path := range paths {
+		onDisk, err := ioutil.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if onDisk == nil || !bytes.Equal(onDisk, files[path]) {
+			drifts = append(drifts, &Drift{Path: path, Generated: files[path], OnDisk: onDisk})
+		}
+	}
+
+	for _, path := range stale {
+		onDisk, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		drifts = append(drifts, &Drift{Path: path, OnDisk: onDisk})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Path < drifts[j].Path
+	})
+
+	return drifts, nil
+}
@@ -0,0 +1,249 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"strconv"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// Sink receives the generated files, once all of them were generated
+type Sink interface {
+	WriteFile(path string, data []byte) error
+}
+
+// Files is a Sink keeping the generated files in memory, from path to content
+type Files map[string][]byte
+
+// WriteFile stores a copy of data as the content of path
+func (files Files) WriteFile(path string, data []byte) error {
+	files[path] = append([]byte(nil), data...)
+	return nil
+}
+
+// SyntaxError is returned when the code generated for a declaration is not valid Go,
+// which means the schema uses something the generator does not support
+type SyntaxError struct {
+	Path   string                // the generated file
+	Line   int                   // line of the error in the unformatted file
+	Column int                   // column of the error in the unformatted file
+	Decl   *tlparser.Declaration // the declaration the code was generated for, nil for code not coming from the schema
+	Err    error                 // the first error of parsing the file, which includes its position
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Decl == nil {
+		return fmt.Sprintf("generated invalid code: %s", e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s: generated invalid code: %s", e.Decl.Pos(), declarationName(e.Decl), e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// declarationName returns the name of the combinator or the class declared by decl
+func declarationName(decl *tlparser.Declaration) string {
+	if decl.Combinator != nil {
+		return decl.Combinator.Name
+	}
+	if name, ok := decl.Doc.Tag("class"); ok {
+		return name
+	}
+
+	return "declaration"
+}
+
+// fileSet holds the files being generated, which are built by appending to them piece by piece
+type fileSet struct {
+	buffers  map[string]*bytes.Buffer
+	segments map[string][]segment // which declaration each part of the files comes from
+	paths    []string             // in the order the files were created
+}
+
+// segment is a range of lines of a generated file, generated for decl
+type segment struct {
+	from, to int
+	decl     *tlparser.Declaration
+	named    map[string]*tlparser.Declaration // see appendNamed
+}
+
+func newFileSet() *fileSet {
+	return &fileSet{buffers: map[string]*bytes.Buffer{}, segments: map[string][]segment{}}
+}
+
+// exists reports whether something was already generated in the file at path
+func (files *fileSet) exists(path string) bool {
+	_, ok := files.buffers[path]
+	return ok
+}
+
+// append adds data, generated for decl, at the end of the file at path, creating it if needed.
+// decl is nil for code that does not come from the schema.
+func (files *fileSet) append(path string, data []byte, decl *tlparser.Declaration) {
+	files.appendNamed(path, data, decl, nil)
+}
+
+// appendNamed is append for data generated for several declarations, such as the interface of an MTProto schema,
+// generated for its classes: lines containing a key of named come from its declaration, the longest key winning,
+// the others from decl
+func (files *fileSet) appendNamed(path string, data []byte, decl *tlparser.Declaration, named map[string]*tlparser.Declaration) {
+	buf, ok := files.buffers[path]
+	if !ok {
+		buf = &bytes.Buffer{}
+		files.buffers[path] = buf
+		files.paths = append(files.paths, path)
+	}
+
+	from := bytes.Count(buf.Bytes(), []byte("\n")) + 1
+	buf.Write(data)
+	files.segments[path] = append(files.segments[path], segment{from: from, to: from + bytes.Count(data, []byte("\n")), decl: decl, named: named})
+}
+
+// writeTo formats the files and writes them to sink, in the order they were created.
+// Nothing is written if one of them does not parse.
+func (files *fileSet) writeTo(sink Sink) error {
+	formatted := make([][]byte, len(files.paths))
+	for i, path := range files.paths {
+		src, err := formatSource(path, files.buffers[path].Bytes())
+		if err != nil {
+			return files.syntaxError(path, err)
+		}
+		formatted[i] = src
+	}
+
+	for i, path := range files.paths {
+		if err := sink.WriteFile(path, formatted[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syntaxError turns the error of parsing the file at path into a *SyntaxError
+func (files *fileSet) syntaxError(path string, err error) error {
+	errs, ok := err.(scanner.ErrorList)
+	if !ok || len(errs) == 0 {
+		return err
+	}
+
+	syntaxErr := &SyntaxError{Path: path, Line: errs[0].Pos.Line, Column: errs[0].Pos.Column, Err: errs[0]}
+	for _, segment := range files.segments[path] {
+		if segment.from <= syntaxErr.Line && syntaxErr.Line <= segment.to {
+			syntaxErr.Decl = segment.decl
+			if segment.named != nil {
+				syntaxErr.Decl = segment.namedDecl(files.line(path, syntaxErr.Line))
+			}
+		}
+	}
+
+	return syntaxErr
+}
+
+// line returns the line of the file at path numbered n, from 1
+func (files *fileSet) line(path string, n int) []byte {
+	lines := bytes.Split(files.buffers[path].Bytes(), []byte("\n"))
+	if n < 1 || n > len(lines) {
+		return nil
+	}
+
+	return lines[n-1]
+}
+
+// namedDecl returns the declaration line comes from, within the segment
+func (segment segment) namedDecl(line []byte) *tlparser.Declaration {
+	decl, longest := segment.decl, ""
+	for name, namedDecl := range segment.named {
+		if len(name) > len(longest) && bytes.Contains(line, []byte(name)) {
+			decl, longest = namedDecl, name
+		}
+	}
+
+	return decl
+}
+
+// formatSource removes the unused imports of the Go source src, then formats it as gofmt would
+func formatSource(filePath string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	// unused imports are cut from the source, which keeps the import blocks formatted as they were
+	var pruned bytes.Buffer
+	offset := 0
+	cut := func(node ast.Node) {
+		from, to := fset.Position(node.Pos()).Offset, fset.Position(node.End()).Offset
+		for from > 0 && (src[from-1] == ' ' || src[from-1] == '\t') {
+			from--
+		}
+		for to < len(src) && (src[to] == ' ' || src[to] == '\t' || src[to] == ';') {
+			to++
+		}
+		if to < len(src) && src[to] == '\n' {
+			to++
+		}
+
+		pruned.Write(src[offset:from])
+		offset = to
+	}
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+
+		var unused []ast.Spec
+		for _, spec := range genDecl.Specs {
+			if name := importName(spec.(*ast.ImportSpec)); name != "" && !used[name] {
+				unused = append(unused, spec)
+			}
+		}
+
+		if len(unused) == len(genDecl.Specs) {
+			cut(genDecl)
+			continue
+		}
+		for _, spec := range unused {
+			cut(spec)
+		}
+	}
+	pruned.Write(src[offset:])
+
+	return format.Source(pruned.Bytes())
+}
+
+// importName returns the name an import is referred to by, "" for '_' and '.' imports
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name != nil {
+		if spec.Name.Name == "_" || spec.Name.Name == "." {
+			return ""
+		}
+		return spec.Name.Name
+	}
+
+	importPath, _ := strconv.Unquote(spec.Path.Value)
+
+	return path.Base(importPath)
+}
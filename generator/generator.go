@@ -1,9 +1,18 @@
 package generator
 
 import (
-	"log"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 
+	"github.com/Arman92/go-tl-parser/naming"
 	"github.com/Arman92/go-tl-parser/tlparser"
 )
 
@@ -11,29 +20,252 @@ const (
 	commonFileName = "common.go"
 )
 
-func GenerateCode(schema *tlparser.TlSchema, basePackageUri, packageName, typesOutputDir, methodsOutputDir string) {
+// ErrNotGenerated is returned (wrapped) when the generator would overwrite a file
+// that it did not generate, that is a file without the AUTOGENERATED header
+var ErrNotGenerated = errors.New("file was not generated, it has no " + defaultHeader + " header")
 
-	os.RemoveAll(typesOutputDir)
-	os.MkdirAll(typesOutputDir, os.ModePerm)
+// Options control what GenerateCode generates and where
+type Options struct {
+	Backend Backend // generates the files, GoBackend if nil
 
-	err := generateCommonFiles(packageName, typesOutputDir)
+	BasePackageURI     string // import path the types and methods packages live under, e.g. github.com/Arman92/go-tdlib
+	PackageName        string // name of the types package, also the last element of its import path
+	MethodsPackageName string // name of the methods package, "client" if empty
+	TypesOutputDir     string
+	MethodsOutputDir   string
+
+	// Naming builds the Go identifiers of types, fields, methods and parameters, naming.Default if nil
+	Naming *naming.Namer
+
+	// BinaryCodec, Context, UnknownTypes and Templates are options of GoBackend, other backends may use them too.
+
+	BinaryCodec bool // also generate the TL binary EncodeTL/DecodeTL methods
+	// Context gives methods a leading ctx context.Context parameter, which they pass to
+	// client.SendAndCatchContext(ctx, request) instead of calling client.SendAndCatch(request).
+	Context bool
+	// UnknownTypes generates an UnknownX type for each interface X, which objects of classes the schema does not
+	// declare, such as those of a newer TDLib, are decoded to rather than failing with ErrUnknownConstructor.
+	UnknownTypes bool
+	// Templates overrides the default templates with its *.tmpl files, such as method.tmpl, if not nil.
+	// Templates are executed with the Model built from the schema.
+	Templates fs.FS
+
+	// Clean removes the generated files of TypesOutputDir that were not generated this time, such as those of
+	// removed types. Files without the AUTOGENERATED header, such as a hand-written tdjson.go, are kept,
+	// and so are the files of MethodsOutputDir.
+	Clean bool
+	// Force allows overwriting files that were not generated, at the path of a generated file.
+	// Without it, GenerateCode fails with ErrNotGenerated rather than touching them.
+	Force bool
+}
+
+// GenerateCode generates the types and methods of schema as described by options, and writes them to disk.
+// Files on disk are only replaced once everything was generated, each of them atomically.
+func GenerateCode(schema *tlparser.TlSchema, options Options) error {
+	files := Files{}
+	if err := Generate(schema, options, files); err != nil {
+		return err
+	}
+
+	return writeFiles(files, options)
+}
+
+// Generate generates the code of schema with options.Backend, GoBackend if nil, and writes it to sink.
+// For GoBackend, paths are those of the files in options.TypesOutputDir and options.MethodsOutputDir.
+// Nothing is written to sink if generation fails.
+func Generate(schema *tlparser.TlSchema, options Options, sink Sink) error {
+	if options.MethodsPackageName == "" {
+		options.MethodsPackageName = "client"
+	}
+
+	backend := options.Backend
+	if backend == nil {
+		backend = GoBackend{}
+	}
+
+	files := Files{}
+	if err := backend.Generate(buildModel(schema, options.names(), options), files); err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := sink.WriteFile(path, files[path]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (options Options) names() *naming.Namer {
+	if options.Naming == nil {
+		return naming.Default
+	}
+
+	return options.Naming
+}
+
+// writeFiles writes files to disk: every file is first written to a temporary file next to it,
+// which only replaces it when all of them were written
+func writeFiles(files Files, options Options) error {
+	paths, stale, err := planWrite(files, options)
 	if err != nil {
-		log.Fatal("Failed to generate/write common file:", err)
+		return err
+	}
+
+	tempPaths := map[string]string{}
+	removeTempFiles := func() {
+		for _, tempPath := range tempPaths {
+			os.Remove(tempPath)
+		}
+	}
+
+	for _, path := range paths {
+		tempPath, err := writeTempFile(path, files[path])
+		if err != nil {
+			removeTempFiles()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		tempPaths[path] = tempPath
+	}
+
+	for _, path := range paths {
+		if err := os.Rename(tempPaths[path], path); err != nil {
+			removeTempFiles()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		delete(tempPaths, path)
 	}
 
-	err = GenerateInterfaceAndEnums(schema, packageName, typesOutputDir)
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planWrite returns the sorted paths of files, and the generated files to remove afterwards as options.Clean is set.
+// Unless options.Force is set, it fails if any of the files to write exists and was not generated.
+func planWrite(files Files, options Options) (paths, stale []string, err error) {
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if options.Clean {
+		stale, err = staleFiles(options.TypesOutputDir, files)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !options.Force {
+		for _, path := range paths {
+			if err := checkGenerated(path); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return paths, stale, nil
+}
+
+// staleFiles returns the generated Go files of dir which are not part of files
+func staleFiles(dir string, files Files) ([]string, error) {
+	var stale []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if _, ok := files[path]; ok || info.IsDir() || filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		generated, err := isGenerated(path)
+		if generated {
+			stale = append(stale, path)
+		}
+
+		return err
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return stale, err
+}
+
+// writeTempFile writes data to a new temporary file in the directory of path, and returns its path
+func writeTempFile(path string, data []byte) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Chmod(0644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		log.Fatal("Failed to generate/write interface/enum files:", err)
+		os.Remove(file.Name())
+		return "", err
 	}
 
-	err = GenerateClasses(schema, packageName, typesOutputDir)
+	return file.Name(), nil
+}
+
+// checkGenerated returns an error wrapping ErrNotGenerated if the file at path exists and was not generated
+func checkGenerated(path string) error {
+	generated, err := isGenerated(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		log.Fatal("Failed to generate/write classes files:", err)
+		return err
+	}
+	if !generated {
+		return fmt.Errorf("refusing to replace %s: %w", path, ErrNotGenerated)
 	}
 
-	err = GenerateMethods(schema, basePackageUri, packageName, "client", methodsOutputDir)
+	return nil
+}
+
+// isGenerated reports whether the file at path starts with the AUTOGENERATED header
+func isGenerated(path string) (bool, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		log.Fatal("Failed to generate/write method files:", err)
+		return false, err
 	}
+	defer file.Close()
 
+	// the header is the first line, possibly after blank ones; lines longer than the buffer of reader are not it
+	reader := bufio.NewReader(file)
+	for {
+		line, isPrefix, err := reader.ReadLine()
+		if err == io.EOF || isPrefix {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return string(line) == defaultHeader, nil
+		}
+	}
 }
@@ -0,0 +1,344 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Arman92/go-tl-parser/naming"
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+const testSchema = `
+//@description A text @text The text
+text text:string = Text;
+
+---functions---
+
+//@description Returns a text @text The text
+getText text:string = Text;
+`
+
+func parseTestSchema(t *testing.T, src string) *tlparser.TlSchema {
+	t.Helper()
+
+	schema, err := tlparser.ParseInputSchema(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testOptions(dir string) Options {
+	return Options{
+		BasePackageURI:   "example.com/td",
+		PackageName:      "tdlib",
+		TypesOutputDir:   filepath.Join(dir, "tdlib"),
+		MethodsOutputDir: filepath.Join(dir, "client"),
+		Clean:            true,
+	}
+}
+
+func TestGenerateCodeClean(t *testing.T) {
+	dir := t.TempDir()
+	options := testOptions(dir)
+	if err := os.MkdirAll(options.TypesOutputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	handWritten := filepath.Join(options.TypesOutputDir, "tdjson.go")
+	stale := filepath.Join(options.TypesOutputDir, "removedType.go")
+	writeTestFile(t, handWritten, "package tdlib\n")
+	writeTestFile(t, stale, defaultHeader+"\n\npackage tdlib\n")
+	// files with lines longer than bufio.Scanner reads are not generated, whether Go files or not
+	longLine := strings.Repeat("x", 100000) + "\n"
+	writeTestFile(t, filepath.Join(options.TypesOutputDir, "data.json"), longLine)
+	writeTestFile(t, filepath.Join(options.TypesOutputDir, "table.go"), "// "+longLine+"package tdlib\n")
+
+	if err := GenerateCode(parseTestSchema(t, testSchema), options); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tdjson.go", "data.json", "table.go"} {
+		if _, err := os.Stat(filepath.Join(options.TypesOutputDir, name)); err != nil {
+			t.Errorf("the hand-written file was removed: %v", err)
+		}
+	}
+	if _, err := os.Stat(stale); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the stale generated file was kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(options.TypesOutputDir, "text.go")); err != nil {
+		t.Error(err)
+	}
+
+	drifts, err := CheckCode(parseTestSchema(t, testSchema), options)
+	if err != nil || len(drifts) != 0 {
+		t.Errorf("got drifts %v, %v right after generating", drifts, err)
+	}
+}
+
+func TestGenerateCodeNotGenerated(t *testing.T) {
+	dir := t.TempDir()
+	options := testOptions(dir)
+	if err := os.MkdirAll(options.TypesOutputDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// a hand-written file where a generated one goes
+	handWritten := filepath.Join(options.TypesOutputDir, "text.go")
+	writeTestFile(t, handWritten, "package tdlib\n")
+
+	err := GenerateCode(parseTestSchema(t, testSchema), options)
+	if !errors.Is(err, ErrNotGenerated) {
+		t.Fatalf("got %v, want ErrNotGenerated", err)
+	}
+	if _, err := CheckCode(parseTestSchema(t, testSchema), options); !errors.Is(err, ErrNotGenerated) {
+		t.Fatalf("CheckCode: got %v, want ErrNotGenerated", err)
+	}
+
+	options.Force = true
+	if err := GenerateCode(parseTestSchema(t, testSchema), options); err != nil {
+		t.Fatal(err)
+	}
+	if generated, err := isGenerated(handWritten); !generated || err != nil {
+		t.Errorf("the file was not overwritten: %v", err)
+	}
+}
+
+func TestBuildModelMTProtoTypes(t *testing.T) {
+	schema := parseTestSchema(t, `
+int128 4*[ int ] = Int128;
+user#d3bc4b7a id:long flags:# bot:flags.0?true key:int128 name:flags.1?string photo:flags.2?bytes = User;
+
+---functions---
+
+invokeWithLayer#da9b0d0d {X:Type} layer:int query:!X = X;
+users.getUsers#d91a548 id:Vector<long> = Vector<User>;
+account.registerDevice#ec86017a token_type:int = Bool;
+`)
+	model := buildModel(schema, naming.Default, testOptions(""))
+
+	fields := map[string]*Field{}
+	for _, field := range model.Classes[0].Fields {
+		fields[field.Name] = field
+	}
+	for _, test := range []struct{ name, goType string }{
+		{"id", "int64"}, {"flags", "uint32"}, {"bot", "bool"}, {"key", "[16]byte"}, {"name", "*string"}, {"photo", "[]byte"},
+	} {
+		if field := fields[test.name]; field.GoType != test.goType {
+			t.Errorf("field %s: got %s, want %s", test.name, field.GoType, test.goType)
+		}
+	}
+	if kind := fields["flags"].Type.Kind; kind != FlagsType {
+		t.Errorf("flags: got kind %s", kind)
+	}
+
+	results := map[string]string{}
+	for _, function := range model.Functions {
+		result := function.Result
+		if function.ResultPointer {
+			result = "*" + result
+		}
+		results[function.Name] = result
+	}
+	for name, want := range map[string]string{"invokeWithLayer": "json.RawMessage", "users.getUsers": "[]tdlib.User", "account.registerDevice": "*bool"} {
+		if results[name] != want {
+			t.Errorf("%s: got result %s, want %s", name, results[name], want)
+		}
+	}
+
+	query := model.Functions[0].Params[1]
+	if query.Type.Kind != GenericType || query.GoType != "tdlib.TdMessage" || query.RequestType != "TdMessage" {
+		t.Errorf("query: got %s %s %s", query.Type.Kind, query.GoType, query.RequestType)
+	}
+}
+
+func TestSyntaxErrorDecl(t *testing.T) {
+	// the interface Bar, which has no declaration, gets the invalid enum constant '2barType'
+	schema := parseTestSchema(t, "bar a:int32 = Bar;\n2bar a:int32 = Bar;\n")
+	err := GenerateCode(schema, testOptions(t.TempDir()))
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("got %v, want a SyntaxError", err)
+	}
+	if syntaxErr.Decl == nil || syntaxErr.Decl.Combinator == nil || syntaxErr.Decl.Combinator.Name != "2bar" {
+		t.Errorf("got %v, want the error at the declaration of 2bar", err)
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	tests := []struct {
+		schema      string
+		name, other string // declarations, with their line
+		identifier  string
+	}{
+		{"foo_bar a:int32 = FooBar;\nfooBar a:int32 = FooBar;\n", "class fooBar (<input>:2:1)", "class foo_bar (<input>:1:1)", "FooBar"},
+		{"foo chat_id:int32 chatId:int32 = Foo;\n", "field chatId of class foo (<input>:1:1)", "field chat_id of class foo (<input>:1:1)", "Foo.ChatID"},
+		{"foo a:int32 = Foo;\n---functions---\nget_chat = Foo;\ngetChat = Foo;\n", "function getChat (<input>:4:1)", "function get_chat (<input>:3:1)", "Client.GetChat"},
+		{"requestError a:int32 = RequestError;\n", "class requestError (<input>:1:1)", "a type of common.go", "RequestError"},
+	}
+
+	for _, test := range tests {
+		err := GenerateCode(parseTestSchema(t, test.schema), testOptions(t.TempDir()))
+
+		var collision *NameCollisionError
+		if !errors.As(err, &collision) {
+			t.Errorf("%q: got %v, want a NameCollisionError", test.schema, err)
+			continue
+		}
+		want := fmt.Sprintf("%s and %s both map to the Go identifier %s, rename one of them", test.name, test.other, test.identifier)
+		if err.Error() != want {
+			t.Errorf("%q: got %q, want %q", test.schema, err, want)
+		}
+		if collision.Decl == nil || (collision.OtherDecl == nil) != (test.other == "a type of common.go") {
+			t.Errorf("%q: got declarations %v and %v", test.schema, collision.Decl, collision.OtherDecl)
+		}
+	}
+}
+
+// runGenerated generates schema, with the BinaryCodec, Context and UnknownTypes of options, into a module in a temporary
+// directory, copies each testdata file of testFiles into the package directory it maps to ('tdlib' or 'client'),
+// and runs go with args there
+func runGenerated(t *testing.T, schema *tlparser.TlSchema, options Options, testFiles map[string]string, args ...string) {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("builds generated code")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	generated := testOptions(dir)
+	generated.BinaryCodec, generated.Context, generated.UnknownTypes = options.BinaryCodec, options.Context, options.UnknownTypes
+	if err := GenerateCode(schema, generated); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, filepath.Join(dir, "go.mod"), "module example.com/td\n\ngo 1.16\n")
+	for name, pkg := range testFiles {
+		content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, filepath.Join(dir, pkg, filepath.Base(name)), string(content))
+	}
+
+	cmd := exec.Command(goTool, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+}
+
+func parseTestSchemaFile(t *testing.T, path string) *tlparser.TlSchema {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	schema, err := tlparser.ParseInputSchemaFile(filepath.Base(path), file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+// TestBinaryCodecRoundTrip generates the types of the MTProto schema api.tl of tlparser,
+// and runs testdata/binary_test.go against them
+func TestBinaryCodecRoundTrip(t *testing.T) {
+	schema := parseTestSchemaFile(t, filepath.Join("..", "tlparser", "testdata", "api.tl"))
+	runGenerated(t, schema, Options{BinaryCodec: true}, map[string]string{"binary_test.go": "tdlib"}, "test", "./tdlib")
+}
+
+// TestGeneratedClient generates the td_api.tl of tlparser, and runs the tests and benchmarks of testdata/client,
+// which stubs the Client the methods are sent with, against them
+func TestGeneratedClient(t *testing.T) {
+	schema := parseTestSchemaFile(t, filepath.Join("..", "tlparser", "testdata", "td_api.tl"))
+	runGenerated(t, schema, Options{BinaryCodec: true}, map[string]string{
+		filepath.Join("client", "client.go"):      "client",
+		filepath.Join("client", "client_test.go"): "client",
+	}, "test", "-bench=.", "-benchtime=1x", "./...")
+}
+
+// TestGeneratedContext generates testSchema with Options.Context, and runs testdata/client/context_test.go against it
+func TestGeneratedContext(t *testing.T) {
+	runGenerated(t, parseTestSchema(t, testSchema), Options{Context: true}, map[string]string{
+		filepath.Join("client", "client.go"):       "client",
+		filepath.Join("client", "context_test.go"): "client",
+	}, "test", "./client")
+}
+
+const unknownTypesSchema = `
+//@class Content @description A content
+
+//@description A text @text The text
+contentText text:string = Content;
+
+//@description A message @id Its identifier @content Its content
+message id:int53 content:Content = Message;
+
+---functions---
+
+//@description Returns a content
+getContent = Content;
+`
+
+// TestGeneratedUnknownTypes generates unknownTypesSchema with Options.UnknownTypes, and runs
+// testdata/client/unknown_test.go against it
+func TestGeneratedUnknownTypes(t *testing.T) {
+	runGenerated(t, parseTestSchema(t, unknownTypesSchema), Options{UnknownTypes: true}, map[string]string{
+		filepath.Join("client", "client.go"):       "client",
+		filepath.Join("client", "unknown_test.go"): "client",
+	}, "test", "./client")
+}
+
+// TestGenerateResultVars builds the methods of functions whose result variable would be a local of the method,
+// a keyword or the types package
+func TestGenerateResultVars(t *testing.T) {
+	schema := parseTestSchema(t, `
+result value:int = Result;
+request value:int = Request;
+resultType value:int = ResultType;
+err value:int = Err;
+typeValue value:int = Type;
+tdlib value:int = Tdlib;
+
+---functions---
+
+getResult = Result;
+getRequest = Request;
+getResultType = ResultType;
+getErr = Err;
+getType = Type;
+getTdlib = Tdlib;
+`)
+	for _, function := range buildModel(schema, naming.Default, testOptions("")).Functions {
+		if !strings.HasSuffix(function.ResultVar, "Dummy") {
+			t.Errorf("%s: result variable %s", function.Name, function.ResultVar)
+		}
+	}
+
+	runGenerated(t, schema, Options{BinaryCodec: true}, map[string]string{filepath.Join("client", "client.go"): "client"}, "vet", "./...")
+}
@@ -2,106 +2,46 @@ package generator
 
 import (
 	"bytes"
-	"fmt"
 	"path/filepath"
-	"strings"
+	"strconv"
+	"text/template"
 
 	"github.com/Arman92/go-tl-parser/tlparser"
 )
 
-func GenerateInterfaceAndEnums(schema *tlparser.TlSchema, packageName, outputDir string) error {
+func generateInterfaceAndEnums(model *Model, templates *template.Template, outputDir string, files *fileSet) error {
 
-	for _, interfaceInfo := range schema.Interfaces {
+	for _, modelInterface := range model.Interfaces {
 		buf := bytes.NewBufferString("")
-		appendPackageName(buf, packageName)
-		buf.WriteString(`
-		
-		import (
-			"encoding/json"
-			"fmt"
-		)
-	
-		`)
-
-		interfaceInfo.Name = replaceKeyWords(interfaceInfo.Name)
-		typesCases := ""
-
-		buf.WriteString(fmt.Sprintf("// %s %s \ntype %s interface {\nGet%sEnum() %sEnum\n}\n\n",
-			interfaceInfo.Name, interfaceInfo.Description, interfaceInfo.Name, interfaceInfo.Name, interfaceInfo.Name))
-
-		for _, enum := range schema.Enums {
-
-			// Only include related enums to this interface (abstract class)
-			if enum.EnumType == interfaceInfo.Name+"Enum" {
-
-				// Generate enum and it's types
-				buf.WriteString(fmt.Sprintf(`
-			// %s Alias for abstract %s 'Sub-Classes', used as constant-enum here
-			type %s string
-			`,
-					enum.EnumType,
-					strings.TrimSuffix(enum.EnumType, "Enum"),
-					enum.EnumType))
-
-				constStr := ""
-				for _, item := range enum.Items {
-					// Generate enum const items
-					constStr += fmt.Sprintf(`%sType %s = "%s"%s`, item.GolangType, enum.EnumType, item.OriginalType, "\n")
-
-					typeName := item.GolangType
-					typeNameCamel := firstLower(typeName)
-					typesCases += fmt.Sprintf(`case %s:
-						var %s %s
-						err := json.Unmarshal(*rawMsg, &%s)
-						return &%s, err
-						
-						`,
-						item.GolangType+"Type", typeNameCamel, typeName,
-						typeNameCamel, typeNameCamel)
-
-				}
-
-				buf.WriteString(fmt.Sprintf(`
-			// %s enums
-			const (
-				%s
-			)`, strings.TrimSuffix(enum.EnumType, "Enum"), constStr))
-
-				// We won't process other enums as it's unnecessary
-				break
-			}
-
-			// Generate Unmarshal functions
+		appendPackageName(buf, model.TypesPackage)
+		if err := executeTemplate(buf, templates, "imports", typesImports); err != nil {
+			return err
+		}
 
+		if err := executeTemplate(buf, templates, "enum", modelInterface); err != nil {
+			return err
 		}
 
-		buf.WriteString(fmt.Sprintf(`
-		func unmarshal%s(rawMsg *json.RawMessage) (%s, error){
+		filePath := filepath.Join(outputDir, modelInterface.File)
+		decl, named := interfaceDecls(modelInterface)
+		files.appendNamed(filePath, buf.Bytes(), decl, named)
+	}
 
-			if rawMsg == nil {
-				return nil, nil
-			}
-			var objMap map[string]interface{}
-			err := json.Unmarshal(*rawMsg, &objMap)
-			if err != nil {
-				return nil, err
-			}
+	return nil
+}
 
-			switch %sEnum(objMap["@type"].(string)) {
-				%s
-			default:
-				return nil, fmt.Errorf("Error UnMarshaling, unknown type:" +  objMap["@type"].(string))
-			}
-		}
-		`, interfaceInfo.Name, interfaceInfo.Name, interfaceInfo.Name,
-			typesCases))
+// interfaceDecls returns the declaration the code of modelInterface comes from. Interfaces of MTProto schemas have
+// none: their code comes from the declarations of their classes, by the Go and TL names of the classes it uses.
+func interfaceDecls(modelInterface *Interface) (*tlparser.Declaration, map[string]*tlparser.Declaration) {
+	if modelInterface.Decl != nil || len(modelInterface.Classes) == 0 {
+		return modelInterface.Decl, nil
+	}
 
-		filePath := filepath.Join(outputDir, firstLower(replaceKeyWords(interfaceInfo.Name))+".go")
-		err := writeToFileAndFormat(buf.Bytes(), filePath)
-		if err != nil {
-			return err
-		}
+	named := map[string]*tlparser.Declaration{}
+	for _, class := range modelInterface.Classes {
+		named[class.GoName] = class.Decl
+		named[strconv.Quote(class.Name)] = class.Decl
 	}
 
-	return nil
+	return modelInterface.Classes[0].Decl, named
 }
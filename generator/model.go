@@ -0,0 +1,504 @@
+package generator
+
+import (
+	"go/token"
+	"strings"
+
+	"github.com/Arman92/go-tl-parser/naming"
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// Model is what the templates are executed with: the declarations of a TlSchema along with the Go names and types
+// the generator gives them. Names are TL names, GoNames the Go identifiers.
+type Model struct {
+	Schema  *tlparser.TlSchema
+	Options Options // the options generation was run with, MethodsPackageName being set
+
+	TypesPackage   string // name of the types package, e.g. 'tdlib'
+	TypesImport    string // import path of the types package
+	MethodsPackage string // name of the methods package, e.g. 'client'
+	BinaryCodec    bool   // whether the TL binary codec is generated
+	Context        bool   // whether methods take a context.Context
+
+	Interfaces []*Interface
+	Classes    []*Class
+	Functions  []*Function
+}
+
+// Interface is an abstract class, declared with '//@class', its classes being the items of its enum.
+// In MTProto schemas, which have no '//@class', the types of several classes, or of a class of another name, are too.
+type Interface struct {
+	Name        string
+	GoName      string
+	EnumName    string // e.g. 'MessageContentEnum'
+	UnknownName string // the type unknown classes are decoded to, e.g. 'UnknownMessageContent', set with UnknownTypes
+	Description string
+	Classes     []*Class // in the order of the schema
+	File        string   // name of the file it is generated in
+
+	Info *tlparser.InterfaceInfo // nil for the types of MTProto schemas
+	Decl *tlparser.Declaration
+}
+
+// Class is a constructor, which becomes a struct
+type Class struct {
+	Name        string // also the '@type' of its JSON objects
+	GoName      string
+	ID          uint32 // constructor ID
+	Description string
+	Interface   *Interface // the interface the class implements, nil if its type is not an abstract class
+	Fields      []*Field
+	File        string // name of the file it is generated in, that of its type
+
+	Info *tlparser.ClassInfo
+	Decl *tlparser.Declaration
+}
+
+// HasInterfaceFields reports whether any field of the class is an interface, which JSON cannot unmarshal by itself
+func (class *Class) HasInterfaceFields() bool {
+	for _, field := range class.Fields {
+		if field.Type.IsInterface() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Field is a field of a class, or a parameter of a function
+type Field struct {
+	Name        string // also its key in JSON objects
+	GoName      string // name of the struct field
+	ParamName   string // name of the parameter of constructors and methods
+	Type        *Type
+	GoType      string // Go type of the struct field and constructor parameter, or of the method parameter for functions
+	RequestType string // for functions, the Go type of the field of the request struct, GoType in the types package
+	Description string
+
+	// OmitEmpty is set for fields of requests which may be left out, see Type.Nullable
+	OmitEmpty bool
+
+	// TL is the field of the struct, or of the request, as the encodeValue and decodeValue templates write and read it,
+	// set with BinaryCodec
+	TL *TLValue
+}
+
+// TypeKind tells what kind of type a Type is, or the items of vectors are
+type TypeKind string
+
+// Kinds of Type
+const (
+	PrimitiveType TypeKind = "primitive" // int32, int53, int64, double, string, bytes, Bool, or int, long, int128, int256 and true of MTProto
+	FlagsType     TypeKind = "flags"     // '#', the bits telling which conditional fields are set
+	GenericType   TypeKind = "generic"   // a type parameter, such as X of '{X:Type} query:!X = X', or Object: any object
+	ClassType     TypeKind = "class"
+	InterfaceType TypeKind = "interface"
+)
+
+// Type is the resolved type of a field or of the result of a function
+type Type struct {
+	Name        string // TL name of the type, or of the items of vectors, e.g. 'photoSize' for 'vector<photoSize>'
+	Kind        TypeKind
+	VectorDepth int  // 1 for 'vector<T>', 2 for 'vector<vector<T>>'
+	Bare        bool // for '%T', whose values are written without their constructor ID in TL binary data
+	// BoxedVectors tells, from the outermost vector in, whether each is 'Vector<T>', written with its constructor ID
+	// in TL binary data, rather than 'vector<T>', only the length of which is
+	BoxedVectors []bool
+
+	Primitive string     // TL name of PrimitiveType and FlagsType types, e.g. 'int53'
+	Class     *Class     // the class of ClassType types, nil if the schema does not declare it
+	Interface *Interface // the interface of InterfaceType types
+
+	// Nullable is set for conditional fields, such as 'flags.0?string', and for fields documented as 'may be null'
+	Nullable bool
+	// Conditional is set for conditional fields, only written to TL binary data if their bit of the flags is set
+	Conditional bool
+
+	GoName string // Go name of the type, or of the items of vectors, e.g. 'int64' for 'int53' or 'JSONInt64' for 'int64'
+}
+
+// IsInterface reports whether values of the type are interfaces, which vectors are not
+func (t *Type) IsInterface() bool {
+	return t.Kind == InterfaceType && t.VectorDepth == 0
+}
+
+// IsObject reports whether values of the type are objects with a '@type', which vectors and primitives are not
+func (t *Type) IsObject() bool {
+	return t.VectorDepth == 0 && (t.Kind == ClassType || t.Kind == InterfaceType || t.Kind == GenericType)
+}
+
+// String returns the TL type, e.g. 'vector<photoSize>' or 'Vector<%Message>'
+func (t *Type) String() string {
+	name := t.Name
+	if t.Bare {
+		name = "%" + name
+	}
+	for i := len(t.BoxedVectors) - 1; i >= 0; i-- {
+		if t.BoxedVectors[i] {
+			name = "Vector<" + name + ">"
+		} else {
+			name = "vector<" + name + ">"
+		}
+	}
+
+	return name
+}
+
+// primitiveGoTypes maps the primitive TL types to Go types
+var primitiveGoTypes = map[string]string{
+	"int32":  "int32",
+	"int53":  "int64",
+	"int64":  "JSONInt64",
+	"double": "float64",
+	"string": "string",
+	"bytes":  "[]byte",
+	"Bool":   "bool",
+
+	// MTProto
+	"#":      "uint32",
+	"int":    "int32",
+	"long":   "int64",
+	"int128": "[16]byte",
+	"int256": "[32]byte",
+	"true":   "bool",
+}
+
+// genericGoType is the Go type of GenericType values, any object
+const genericGoType = "TdMessage"
+
+// mtprotoInterfaces returns the types, in the order of the schema, which are not declared with '//@class' but are
+// the type of several classes, or of a class of another name, as in MTProto schemas: 'inputPeerSelf = InputPeer'
+func mtprotoInterfaces(schema *tlparser.TlSchema, interfaces map[string]*Interface, names *naming.Namer) []string {
+	var typeNames []string
+	classes := map[string]int{}
+	for _, classInfo := range schema.Classes {
+		if interfaces[classInfo.RootName] != nil {
+			continue
+		}
+
+		if classes[classInfo.RootName] == 0 {
+			typeNames = append(typeNames, classInfo.RootName)
+		}
+		classes[classInfo.RootName]++
+		if structName(classInfo.Name, names) != names.Type(classInfo.RootName) {
+			// counts as several
+			classes[classInfo.RootName]++
+		}
+	}
+
+	var mtprotoTypes []string
+	for _, typeName := range typeNames {
+		if classes[typeName] > 1 {
+			mtprotoTypes = append(mtprotoTypes, typeName)
+		}
+	}
+
+	return mtprotoTypes
+}
+
+// typeResolver resolves TL types to the interfaces and classes of a Model
+type typeResolver struct {
+	names      *naming.Namer
+	interfaces map[string]*Interface // by TL and Go name
+	classes    map[string]*Class     // by name, and by type for the types which are not interfaces
+}
+
+// resolve returns the Type of tlType, such as 'vector<MessageContent>', in a declaration with typeParams
+func (r *typeResolver) resolve(tlType string, typeParams []tlparser.TypeParam) *Type {
+	t := &Type{Name: tlType}
+	for strings.HasPrefix(strings.ToLower(t.Name), "vector<") && strings.HasSuffix(t.Name, ">") {
+		t.BoxedVectors = append(t.BoxedVectors, t.Name[0] == 'V')
+		t.Name = t.Name[len("vector<") : len(t.Name)-1]
+		t.VectorDepth++
+	}
+	if strings.HasPrefix(t.Name, "%") {
+		t.Name, t.Bare = t.Name[1:], true
+	}
+
+	if goType, ok := primitiveGoTypes[t.Name]; ok {
+		t.Kind, t.Primitive, t.GoName = PrimitiveType, t.Name, goType
+		if t.Name == "#" {
+			t.Kind = FlagsType
+		}
+	} else if name := strings.TrimPrefix(t.Name, "!"); name != t.Name || isTypeParam(name, typeParams) {
+		t.Name, t.Kind, t.GoName = name, GenericType, genericGoType
+	} else if modelInterface, ok := r.interfaces[t.Name]; ok {
+		t.Kind, t.Interface, t.GoName = InterfaceType, modelInterface, modelInterface.GoName
+	} else if class, ok := r.classes[t.Name]; ok || t.Name != "Object" {
+		t.Kind, t.Class, t.GoName = ClassType, class, r.names.Type(t.Name)
+	} else {
+		// Object of MTProto, unless the schema declares it
+		t.Kind, t.GoName = GenericType, genericGoType
+	}
+
+	return t
+}
+
+// resolveProperty returns the Type of a field of a declaration with typeParams
+func (r *typeResolver) resolveProperty(property tlparser.Property, typeParams []tlparser.TypeParam) *Type {
+	t := r.resolve(property.Type, typeParams)
+	t.Conditional = property.IsConditional()
+	t.Nullable = t.Conditional || strings.Contains(strings.ToLower(property.Description), "may be null")
+
+	return t
+}
+
+func isTypeParam(name string, typeParams []tlparser.TypeParam) bool {
+	for _, typeParam := range typeParams {
+		if typeParam.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOptionalValue reports whether t is the type of a conditional field the Go values of which cannot be nil, such as
+// 'flags.0?int': they are pointers, so that a zero value is told from an unset one. 'flags.N?true' fields are the bit.
+func isOptionalValue(t *Type) bool {
+	return t.Conditional && t.VectorDepth == 0 && t.Kind == PrimitiveType && t.Primitive != "true" && t.Primitive != "bytes"
+}
+
+// goFieldType returns the Go type of struct fields and constructor parameters of type t:
+// classes are pointers, unless they are items of vectors, and so are the values of conditional fields
+func goFieldType(t *Type) string {
+	if t.VectorDepth > 0 {
+		return strings.Repeat("[]", t.VectorDepth) + t.GoName
+	}
+	if t.Kind == ClassType || isOptionalValue(t) {
+		return "*" + t.GoName
+	}
+
+	return t.GoName
+}
+
+// goParamType returns the Go type of method parameters of type t, the types package being typesPackage,
+// or "" within the types package itself
+func goParamType(t *Type, typesPackage string) string {
+	vectors := strings.Repeat("[]", t.VectorDepth)
+	if isOptionalValue(t) && t.GoName != "JSONInt64" {
+		return "*" + t.GoName
+	}
+	if (t.Kind == PrimitiveType || t.Kind == FlagsType) && t.GoName != "JSONInt64" {
+		return vectors + t.GoName
+	}
+
+	goName := t.GoName
+	if typesPackage != "" {
+		goName = typesPackage + "." + goName
+	}
+	if t.Kind == InterfaceType || t.Kind == GenericType || t.VectorDepth > 0 {
+		return vectors + goName
+	}
+
+	// classes and JSONInt64 are passed by pointer
+	return "*" + goName
+}
+
+// goResultType returns the Go type the result of a method, of type t, is unmarshaled to:
+// the types package being typesPackage, generic results are left as raw JSON
+func goResultType(t *Type, typesPackage string) string {
+	if t.Kind == GenericType && t.VectorDepth == 0 {
+		return "json.RawMessage"
+	}
+
+	goName := t.GoName
+	if (t.Kind != PrimitiveType && t.Kind != FlagsType) || goName == "JSONInt64" {
+		goName = typesPackage + "." + goName
+	}
+
+	return strings.Repeat("[]", t.VectorDepth) + goName
+}
+
+// resultFileName returns the name of the files of the functions returning t, that of the type of its items for vectors
+func resultFileName(t *Type, names *naming.Namer) string {
+	if t.Kind == GenericType {
+		return firstLower(t.GoName) + ".go"
+	}
+
+	return firstLower(names.Type(t.Name)) + ".go"
+}
+
+// Function is a function, which becomes a method of the Client
+type Function struct {
+	Name         string
+	GoName       string
+	ID           uint32 // constructor ID
+	Description  string
+	Params       []*Field
+	TypesPackage string // name of the types package, which the method refers to
+	RequestName  string // name of the struct of the request in the types package, e.g. 'SendMessageRequest'
+	Context      bool   // whether the method takes a ctx context.Context first
+
+	ReturnType      *Type
+	Result          string     // Go type the result is unmarshaled to, qualified with the types package, e.g. 'tdlib.Chat'
+	ResultPointer   bool       // whether the method returns a pointer to Result, which it does unless it is a slice or interface
+	ResultInterface *Interface // set if the result is an interface, which methods return as is rather than a pointer to
+	ResultVar       string     // name of the variable the result is unmarshaled to, which no parameter or local uses
+	File            string     // name of the files the method and the request are generated in, that of its result type
+
+	Info *tlparser.FunctionInfo
+	Decl *tlparser.Declaration
+}
+
+// buildModel returns the Model of schema, named by names
+func buildModel(schema *tlparser.TlSchema, names *naming.Namer, options Options) *Model {
+	model := &Model{
+		Schema:         schema,
+		Options:        options,
+		TypesPackage:   options.PackageName,
+		TypesImport:    options.BasePackageURI + "/" + options.PackageName,
+		MethodsPackage: options.MethodsPackageName,
+		BinaryCodec:    options.BinaryCodec,
+		Context:        options.Context,
+	}
+
+	resolver := &typeResolver{names: names, interfaces: map[string]*Interface{}, classes: map[string]*Class{}}
+	for _, interfaceInfo := range schema.Interfaces {
+		goName := names.Type(interfaceInfo.Name)
+		modelInterface := &Interface{
+			Name:        interfaceInfo.Name,
+			GoName:      goName,
+			EnumName:    goName + "Enum",
+			Description: interfaceInfo.Description,
+			File:        firstLower(goName) + ".go",
+			Info:        interfaceInfo,
+			Decl:        interfaceInfo.Decl,
+		}
+
+		if options.UnknownTypes {
+			modelInterface.UnknownName = "Unknown" + goName
+		}
+
+		model.Interfaces = append(model.Interfaces, modelInterface)
+		resolver.interfaces[interfaceInfo.Name] = modelInterface
+		if _, ok := resolver.interfaces[goName]; !ok {
+			resolver.interfaces[goName] = modelInterface
+		}
+	}
+
+	structNames := map[string]bool{}
+	for _, classInfo := range schema.Classes {
+		structNames[structName(classInfo.Name, names)] = true
+	}
+	for _, typeName := range mtprotoInterfaces(schema, resolver.interfaces, names) {
+		goName := names.Type(typeName)
+		if structNames[goName] || resolver.interfaces[goName] != nil {
+			// the struct of a class, e.g. that of user for User, or another interface has its name
+			goName += "Class"
+		}
+		modelInterface := &Interface{
+			Name:     typeName,
+			GoName:   goName,
+			EnumName: goName + "Enum",
+			File:     firstLower(names.Type(typeName)) + ".go",
+		}
+
+		if options.UnknownTypes {
+			modelInterface.UnknownName = "Unknown" + goName
+		}
+
+		model.Interfaces = append(model.Interfaces, modelInterface)
+		resolver.interfaces[typeName] = modelInterface
+	}
+
+	for _, classInfo := range schema.Classes {
+		class := &Class{
+			Name:        classInfo.Name,
+			GoName:      structName(classInfo.Name, names),
+			ID:          classInfo.ConstructorID(),
+			Description: classInfo.Description,
+			Interface:   resolver.interfaces[classInfo.RootName],
+			File:        firstLower(names.Type(classInfo.RootName)) + ".go",
+			Info:        classInfo,
+			Decl:        classInfo.Decl,
+		}
+		if class.Interface != nil {
+			class.Interface.Classes = append(class.Interface.Classes, class)
+		} else if _, ok := resolver.classes[classInfo.RootName]; !ok {
+			resolver.classes[classInfo.RootName] = class
+		}
+		resolver.classes[classInfo.Name] = class
+
+		model.Classes = append(model.Classes, class)
+	}
+
+	// fields are resolved once all the classes are known
+	for _, class := range model.Classes {
+		for _, prop := range class.Info.Properties {
+			field := &Field{
+				Name:        prop.Name,
+				GoName:      fieldName(class.Name, prop.Name, names),
+				ParamName:   convertToArgumentName(class.Name, prop.Name, names),
+				Type:        resolver.resolveProperty(prop, class.Info.TypeParams),
+				Description: prop.Description,
+			}
+			field.GoType = goFieldType(field.Type)
+
+			class.Fields = append(class.Fields, field)
+		}
+
+		if options.BinaryCodec {
+			setTLValues(firstLower(class.GoName), class.GoName, class.Fields, class.Info.Properties)
+		}
+	}
+
+	for _, functionInfo := range schema.Functions {
+		resultType := resolver.resolve(functionInfo.ReturnType, functionInfo.TypeParams)
+		function := &Function{
+			Name:         functionInfo.Name,
+			GoName:       methodName(functionInfo.Name, names),
+			ID:           functionInfo.ConstructorID(),
+			Description:  functionInfo.Description,
+			TypesPackage: options.PackageName,
+			RequestName:  methodName(functionInfo.Name, names) + "Request",
+			Context:      options.Context,
+			ReturnType:   resultType,
+			Result:       goResultType(resultType, options.PackageName),
+			ResultVar:    names.Unexported(resultType.Name),
+			File:         resultFileName(resultType, names),
+			Info:         functionInfo,
+			Decl:         functionInfo.Decl,
+		}
+		if resultType.IsInterface() {
+			function.ResultInterface = resultType.Interface
+		}
+		function.ResultPointer = function.ResultInterface == nil && resultType.VectorDepth == 0 && resultType.Kind != GenericType
+		if resultType.Kind == PrimitiveType {
+			// rather than shadowing the Go type of the same name
+			function.ResultVar = "value"
+		}
+
+		// the keys and values of the request, which the result variable must not appear in
+		request := ""
+		for _, param := range functionInfo.Properties {
+			field := &Field{
+				Name:        param.Name,
+				GoName:      fieldName(functionInfo.Name, param.Name, names),
+				ParamName:   convertToArgumentName(functionInfo.Name, param.Name, names),
+				Type:        resolver.resolveProperty(param, functionInfo.TypeParams),
+				Description: param.Description,
+			}
+			field.GoType = goParamType(field.Type, options.PackageName)
+			field.RequestType = goParamType(field.Type, "")
+			field.OmitEmpty = field.Type.Nullable
+
+			request += field.Name + " " + field.ParamName + " "
+			function.Params = append(function.Params, field)
+		}
+		// nor hide a local of the method, a keyword or the types package
+		_, reserved := reservedArgumentNames[function.ResultVar]
+		if reserved || token.IsKeyword(function.ResultVar) || function.ResultVar == options.PackageName ||
+			strings.Contains(request, function.ResultVar) {
+			function.ResultVar += "Dummy"
+		}
+
+		if options.BinaryCodec {
+			setTLValues(firstLower(function.RequestName), function.RequestName, function.Params, functionInfo.Properties)
+		}
+
+		model.Functions = append(model.Functions, function)
+	}
+
+	return model
+}
@@ -0,0 +1,168 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// NameCollisionError is returned when two names of the schema map to the same Go identifier, or one of them
+// maps to an identifier the generated code already uses. One of them must be renamed, see naming.Config.
+type NameCollisionError struct {
+	Identifier string // the Go identifier, qualified with its struct or method if any, e.g. 'Message.ChatID'
+	Name       string // what maps to Identifier, e.g. 'field chat_id of message'
+	Other      string // what else maps to Identifier, or uses it
+	Decl       *tlparser.Declaration
+	OtherDecl  *tlparser.Declaration // nil for identifiers of the generated code itself
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("%s and %s both map to the Go identifier %s, rename one of them",
+		describeName(e.Name, e.Decl), describeName(e.Other, e.OtherDecl), e.Identifier)
+}
+
+func describeName(name string, decl *tlparser.Declaration) string {
+	if decl == nil {
+		return name
+	}
+
+	if decl.Combinator != nil {
+		// the documentation comment comes first, point at the declaration itself
+		return fmt.Sprintf("%s (%s)", name, decl.Combinator.Pos())
+	}
+
+	return fmt.Sprintf("%s (%s)", name, decl.Pos())
+}
+
+// nameScope records the identifiers declared in a Go scope: the types package, a struct, or a function signature
+type nameScope struct {
+	prefix, suffix string // qualify the identifiers in errors, e.g. 'Message.' or 'NewMessage(' and ')'
+	names          map[string]*NameCollisionError
+}
+
+func newNameScope(prefix, suffix string) *nameScope {
+	return &nameScope{prefix: prefix, suffix: suffix, names: map[string]*NameCollisionError{}}
+}
+
+// reserve adds identifiers the generated code declares or uses regardless of the schema, described by what
+func (scope *nameScope) reserve(what string, identifiers ...string) {
+	for _, identifier := range identifiers {
+		scope.names[identifier] = &NameCollisionError{Identifier: scope.qualify(identifier), Name: what}
+	}
+}
+
+func (scope *nameScope) qualify(identifier string) string {
+	return scope.prefix + identifier + scope.suffix
+}
+
+// declare adds identifier, declared for what, and returns an error if it already is in the scope
+func (scope *nameScope) declare(identifier, what string, decl *tlparser.Declaration) error {
+	if previous, ok := scope.names[identifier]; ok {
+		return &NameCollisionError{
+			Identifier: scope.qualify(identifier),
+			Name:       what,
+			Other:      previous.Name,
+			Decl:       decl,
+			OtherDecl:  previous.Decl,
+		}
+	}
+
+	scope.names[identifier] = &NameCollisionError{Identifier: scope.qualify(identifier), Name: what, Decl: decl}
+	return nil
+}
+
+// checkNames returns a NameCollisionError if the generated code would declare an identifier twice in a scope,
+// which happens when names differ in TL but not in Go, e.g. 'fooId' and 'foo_id'
+func checkNames(model *Model) error {
+	// the types declared by common.go
+	common := []string{"TdMessage", "RequestError", "JSONInt64", "UpdateData", "UpdateMsg",
+		"MessageTypeOf", "skipJSONSpace", "scanJSONString", "skipJSONValue", "ErrMissingType", "ErrUnknownConstructor"}
+	if model.BinaryCodec {
+		common = append(common, "TLObject", "Buffer", "NewBuffer", "DecodeObject")
+	}
+	types := newNameScope("", "")
+	types.reserve("a type of common.go", common...)
+
+	for _, modelInterface := range model.Interfaces {
+		what := "interface " + modelInterface.Name
+
+		identifiers := []string{modelInterface.GoName, modelInterface.EnumName, "unmarshal" + modelInterface.GoName}
+		if modelInterface.UnknownName != "" {
+			identifiers = append(identifiers, modelInterface.UnknownName)
+		}
+		if model.BinaryCodec {
+			identifiers = append(identifiers, "decode"+modelInterface.GoName+"TL")
+		}
+		for _, identifier := range identifiers {
+			if err := types.declare(identifier, what, modelInterface.Decl); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, class := range model.Classes {
+		what := "class " + class.Name
+
+		identifiers := []string{class.GoName, "New" + class.GoName}
+		if class.Interface != nil {
+			identifiers = append(identifiers, class.GoName+"Type")
+		}
+		for _, identifier := range identifiers {
+			if err := types.declare(identifier, what, class.Decl); err != nil {
+				return err
+			}
+		}
+
+		methods := []string{"MessageType", "UnmarshalJSON"}
+		if class.Interface != nil {
+			methods = append(methods, "Get"+class.Interface.GoName+"Enum")
+		}
+		if model.BinaryCodec {
+			methods = append(methods, "TLID", "EncodeTL", "DecodeTL")
+		}
+		fields := newNameScope(class.GoName+".", "")
+		fields.reserve("a generated method", methods...)
+		params := newNameScope("New"+class.GoName+"(", ")")
+		for _, field := range class.Fields {
+			what := fmt.Sprintf("field %s of class %s", field.Name, class.Name)
+			if err := fields.declare(field.GoName, what, class.Decl); err != nil {
+				return err
+			}
+			if err := params.declare(field.ParamName, what, class.Decl); err != nil {
+				return err
+			}
+		}
+	}
+
+	methods := newNameScope("Client.", "")
+	for _, function := range model.Functions {
+		if err := methods.declare(function.GoName, "function "+function.Name, function.Decl); err != nil {
+			return err
+		}
+		for _, identifier := range []string{function.RequestName, "New" + function.RequestName} {
+			if err := types.declare(identifier, "function "+function.Name, function.Decl); err != nil {
+				return err
+			}
+		}
+
+		// the types package is referred to by its name in the body of methods
+		params := newNameScope("Client."+function.GoName+"(", ")")
+		params.reserve("the types package", model.TypesPackage)
+		fields := newNameScope(function.RequestName+".", "")
+		fields.reserve("a generated method", "MessageType")
+		if model.BinaryCodec {
+			fields.reserve("a generated method", "TLID", "EncodeTL", "DecodeTL")
+		}
+		for _, param := range function.Params {
+			what := fmt.Sprintf("parameter %s of function %s", param.Name, function.Name)
+			if err := params.declare(param.ParamName, what, function.Decl); err != nil {
+				return err
+			}
+			if err := fields.declare(param.GoName, what, function.Decl); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// defaultTemplates are the templates the Go code is generated with, each file defining the template named after it:
+//   - common: the common.go file of the types package, executed with the Model;
+//   - enum: the interface of an abstract class, its enum, unmarshal function and UnknownX type, executed with an Interface;
+//   - struct: the struct of a class and its methods, executed with a Class, it includes constructor;
+//   - constructor: the NewX function of a class, executed with a Class;
+//   - request: the request struct of a function, sent by its method, executed with a Function;
+//   - method: the Client method of a function, executed with a Function;
+//   - binary and decoder: the TL binary codec of a Class and an Interface, with Options.BinaryCodec;
+//   - requestBinary: the TL binary codec of the request of a Function, with Options.BinaryCodec;
+//   - encodeValue and decodeValue: the statements of binary and requestBinary writing and reading a field, executed
+//     with a TLValue, and recursively with its items for vectors, isSet being the condition of conditional fields;
+//   - imports: the imports of new files, executed with the default import paths, unused ones are removed afterwards.
+//
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// templateFuncs are the functions templates can use besides the predefined ones of text/template
+var templateFuncs = template.FuncMap{
+	"firstLower": firstLower, // e.g. 'messageText' for 'MessageText'
+}
+
+// typesImports and methodsImports are the imports of new files of the types and methods packages,
+// the methods package also imports the types package
+var (
+	typesImports   = []string{"encoding/binary", "encoding/json", "fmt", "io", "math", "strconv", "strings"}
+	methodsImports = []string{"context", "encoding/json", "fmt", "strconv", "strings"}
+)
+
+// loadTemplates returns the default templates, overridden by the *.tmpl files of overrides unless it is nil.
+// As the default ones, each file defines the template named after it, e.g. 'method.tmpl' defines 'method',
+// and may define more templates with {{define}}.
+func loadTemplates(overrides fs.FS) (*template.Template, error) {
+	templates := template.New("").Funcs(templateFuncs)
+
+	if err := parseTemplates(templates, defaultTemplates, "templates"); err != nil {
+		return nil, err
+	}
+	if overrides != nil {
+		if err := parseTemplates(templates, overrides, "."); err != nil {
+			return nil, fmt.Errorf("failed to load templates: %w", err)
+		}
+	}
+
+	return templates, nil
+}
+
+func parseTemplates(templates *template.Template, fsys fs.FS, dir string) error {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no *.tmpl templates found")
+	}
+
+	for _, filePath := range paths {
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(path.Base(filePath), ".tmpl")
+		if _, err := templates.New(name).Parse(string(data)); err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", filePath, err)
+		}
+	}
+
+	return nil
+}
+
+// executeTemplate appends the output of the template name, executed with data, to buf
+func executeTemplate(buf *bytes.Buffer, templates *template.Template, name string, data interface{}) error {
+	if err := templates.ExecuteTemplate(buf, name, data); err != nil {
+		return fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+	buf.WriteString("\n\n")
+
+	return nil
+}
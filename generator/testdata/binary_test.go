@@ -0,0 +1,180 @@
+package tdlib
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// encode returns object boxed
+func encode(t *testing.T, object TLObject) []byte {
+	t.Helper()
+
+	b := NewBuffer(nil)
+	if err := b.PutObject(object); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	fromName, viaBotID := "me", int64(5)
+	objects := []TLObject{
+		&UpdateShortMessage{
+			tdCommon: tdCommon{Type: "updateShortMessage"},
+			Out:      true,
+			Silent:   true,
+			ID:       1,
+			UserID:   2,
+			Message:  "hello",
+			Date:     3,
+			FwdFrom:  &MessageFwdHeader{tdCommon: tdCommon{Type: "messageFwdHeader"}, Imported: true, FromName: &fromName, Date: 4},
+			ViaBotID: &viaBotID,
+			Entities: []MessageEntity{&MessageEntityMentionName{tdCommon: tdCommon{Type: "messageEntityMentionName"}, Length: 5, UserID: 6}},
+		},
+		&MsgContainer{
+			tdCommon: tdCommon{Type: "msg_container"},
+			Messages: []Message{
+				{tdCommon: tdCommon{Type: "message"}, MsgID: 1, Seqno: 2, Bytes: 3, Body: &InputPeerSelf{tdCommon: tdCommon{Type: "inputPeerSelf"}}},
+			},
+		},
+		&ResPQ{
+			tdCommon:                    tdCommon{Type: "resPQ"},
+			Nonce:                       [16]byte{1, 2, 3},
+			ServerNonce:                 [16]byte{15: 4},
+			Pq:                          "pq",
+			ServerPublicKeyFingerprints: []int64{-1, 1},
+		},
+		&InvokeWithLayerRequest{
+			tdCommon: tdCommon{Type: "invokeWithLayer"},
+			Layer:    158,
+			Query: &UsersGetUsersRequest{
+				tdCommon: tdCommon{Type: "users.getUsers"},
+				ID:       []InputUserClass{&InputUser{tdCommon: tdCommon{Type: "inputUser"}, UserID: 1, AccessHash: 2}},
+			},
+		},
+		&MessagesSendMessageRequest{
+			tdCommon:    tdCommon{Type: "messages.sendMessage"},
+			Silent:      true,
+			Peer:        &InputPeerChat{tdCommon: tdCommon{Type: "inputPeerChat"}, ChatID: 1},
+			Message:     "hello",
+			RandomID:    2,
+			ReplyMarkup: &ReplyKeyboardHide{tdCommon: tdCommon{Type: "replyKeyboardHide"}},
+		},
+	}
+
+	for _, object := range objects {
+		data := encode(t, object)
+		decoded, err := DecodeObject(NewBuffer(data))
+		if err != nil {
+			t.Errorf("%s: %v", object.MessageType(), err)
+			continue
+		}
+
+		if !reflect.DeepEqual(decoded, object) {
+			t.Errorf("%s: decoded %+v, want %+v", object.MessageType(), decoded, object)
+		}
+		if again := encode(t, decoded); !bytes.Equal(again, data) {
+			t.Errorf("%s: encoded again to %x, want %x", object.MessageType(), again, data)
+		}
+	}
+}
+
+func TestEncodeFlags(t *testing.T) {
+	header := &MessageReplyHeader{tdCommon: tdCommon{Type: "messageReplyHeader"}, ReplyToScheduled: true, ReplyToMsgID: 7}
+
+	// the true field is only a bit of the flags, the unset int is left out
+	want := []byte{0x63, 0x77, 0xd5, 0xa6, 4, 0, 0, 0, 7, 0, 0, 0}
+	if data := encode(t, header); !bytes.Equal(data, want) {
+		t.Errorf("got %x, want %x", data, want)
+	}
+	if header.Flags != 4 {
+		t.Errorf("got flags %d, want 4", header.Flags)
+	}
+}
+
+func TestEncodeZeroConditionals(t *testing.T) {
+	fromName := ""
+	header := &MessageFwdHeader{tdCommon: tdCommon{Type: "messageFwdHeader"}, FromName: &fromName, Date: 1}
+
+	// an empty from_name is set: its bit, then the empty string padded
+	want := []byte{0xce, 0x7d, 0x77, 0x5f, 0x20, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}
+	data := encode(t, header)
+	if !bytes.Equal(data, want) {
+		t.Errorf("got %x, want %x", data, want)
+	}
+
+	var decoded MessageFwdHeader
+	if err := NewBuffer(data).ReadObject(&decoded); err != nil || decoded.FromName == nil || *decoded.FromName != "" {
+		t.Errorf("got %+v, %v", decoded, err)
+	}
+	if decoded.FromID != nil {
+		t.Errorf("got from_id %d, want it unset", *decoded.FromID)
+	}
+}
+
+// TestGolden checks encodings known from MTProto, the resPQ being the one of the samples of
+// https://core.telegram.org/mtproto/samples-auth_key
+func TestGolden(t *testing.T) {
+	viaBotID := int64(0)
+	tests := []struct {
+		object TLObject
+		hex    string
+	}{
+		{
+			// a bare vector, of bare messages with a boxed body
+			&MsgContainer{
+				tdCommon: tdCommon{Type: "msg_container"},
+				Messages: []Message{
+					{tdCommon: tdCommon{Type: "message"}, MsgID: 0x5e0b700a00000000, Seqno: 3, Bytes: 4, Body: &InputPeerSelf{tdCommon: tdCommon{Type: "inputPeerSelf"}}},
+				},
+			},
+			"dcf8f173" + "01000000" + "000000000a700b5e" + "03000000" + "04000000" + "c97ea07d",
+		},
+		{
+			&ResPQ{
+				tdCommon:                    tdCommon{Type: "resPQ"},
+				Nonce:                       [16]byte{0x3e, 0x05, 0x49, 0x82, 0x8c, 0xca, 0x27, 0xe9, 0x66, 0xb3, 0x01, 0xa4, 0x8f, 0xec, 0xe2, 0xfc},
+				ServerNonce:                 [16]byte{0xa5, 0xcf, 0x4d, 0x33, 0xf4, 0xa1, 0x1e, 0xa8, 0x77, 0xba, 0x4a, 0xa5, 0x73, 0x90, 0x73, 0x30},
+				Pq:                          "\x17\xed\x48\x94\x1a\x08\xf9\x81",
+				ServerPublicKeyFingerprints: []int64{-4344800451088585951},
+			},
+			"63241605" + "3e0549828cca27e966b301a48fece2fc" + "a5cf4d33f4a11ea877ba4aa573907330" +
+				"0817ed48941a08f981000000" + "15c4b51c" + "01000000" + "216be86c022bb4c3",
+		},
+		{
+			// out is only its bit, via_bot_id is set though 0
+			&UpdateShortMessage{
+				tdCommon: tdCommon{Type: "updateShortMessage"},
+				Flags:    0x802,
+				Out:      true,
+				ID:       1,
+				UserID:   2,
+				Message:  "hi",
+				Pts:      3,
+				PtsCount: 1,
+				Date:     4,
+				ViaBotID: &viaBotID,
+			},
+			"f8c73b31" + "02080000" + "01000000" + "0200000000000000" + "02686900" + "03000000" + "01000000" + "04000000" +
+				"0000000000000000",
+		},
+	}
+
+	for _, test := range tests {
+		want, err := hex.DecodeString(test.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data := encode(t, test.object); !bytes.Equal(data, want) {
+			t.Errorf("%s: got %x, want %x", test.object.MessageType(), data, want)
+		}
+
+		decoded, err := DecodeObject(NewBuffer(want))
+		if err != nil || !reflect.DeepEqual(decoded, test.object) {
+			t.Errorf("%s: decoded %+v, %v, want %+v", test.object.MessageType(), decoded, err, test.object)
+		}
+	}
+}
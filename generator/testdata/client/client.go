@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"example.com/td/tdlib"
+)
+
+// Client answers every request with Response, and keeps the last one it was sent
+type Client struct {
+	Response []byte
+	Sent     tdlib.UpdateData
+}
+
+// SendAndCatch sets the @extra of jsonQuery as the SendAndCatch of go-tdlib does
+func (client *Client) SendAndCatch(jsonQuery interface{}) (tdlib.UpdateMsg, error) {
+	var update tdlib.UpdateData
+
+	switch jsonQuery.(type) {
+	case tdlib.TdMessage:
+		if byteArray, err := json.Marshal(jsonQuery); err == nil {
+			json.Unmarshal(byteArray, &update)
+		}
+	case tdlib.UpdateData:
+		update = jsonQuery.(tdlib.UpdateData)
+	}
+	update["@extra"] = "42"
+	client.Sent = update
+
+	return tdlib.UpdateMsg{Raw: client.Response}, nil
+}
+
+// SendAndCatchContext fails if ctx is done, and calls SendAndCatch otherwise
+func (client *Client) SendAndCatchContext(ctx context.Context, jsonQuery interface{}) (tdlib.UpdateMsg, error) {
+	if err := ctx.Err(); err != nil {
+		return tdlib.UpdateMsg{}, err
+	}
+
+	return client.SendAndCatch(jsonQuery)
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"example.com/td/tdlib"
+)
+
+var (
+	payload = []byte(`{"@type":"message","id":123456789,"chat_id":-1001234567890,"content":{"@type":"messageText","text":"` +
+		strings.Repeat(`hello \"world\" `, 20) + `","web_page_id":"9223372036854775807"},"@extra":"a1b2c3"}`)
+	// '@type' comes last, after values containing what looks like it
+	late = []byte(`{"id":1,"chat_id":2,"nested":{"@type":"fake","a":[1,{"b":"}"}]},"s":"\"@type\"",` +
+		`"content":{"@type":"messageText","text":"x","web_page_id":1},"@type":"message"}`)
+)
+
+func TestMessageTypeOf(t *testing.T) {
+	tests := map[string]string{
+		`{"@type":"ok"}`: "ok",
+		` { "a" : [1, 2, {"x":"]"}] , "@type" : "xyz" } `: "xyz",
+		`{"a":null,"b":true,"c":-1.5e3,"@type":"t"}`:      "t",
+		`{"@type":"error","code":400,"message":"Bad"}`:    "error",
+		`{"@type":"a\"b"}`: "a\"b",
+		string(late):       "message",
+	}
+	for data, want := range tests {
+		if got, err := tdlib.MessageTypeOf([]byte(data)); err != nil || got != want {
+			t.Errorf("%s: got %q, %v, want %q", data, got, err, want)
+		}
+	}
+
+	for _, data := range []string{``, `[]`, `null`, `{"a"`, `{"a":"unterminated`, `{"a":{"b":1}`} {
+		if got, err := tdlib.MessageTypeOf([]byte(data)); err == nil {
+			t.Errorf("%s: got %q, want an error", data, got)
+		}
+	}
+	for _, data := range []string{`{}`, `{"a":1}`} {
+		if _, err := tdlib.MessageTypeOf([]byte(data)); !errors.As(err, new(tdlib.ErrMissingType)) {
+			t.Errorf("%s: got %v, want ErrMissingType", data, err)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	client := &Client{Response: []byte(`{"@type":"message","id":1}`)}
+	if message, err := client.GetMessage(1, 2); err != nil || message.ID != 1 {
+		t.Errorf("got %v, %v", message, err)
+	}
+	if client.Sent["@type"] != "getMessage" || client.Sent["@extra"] != "42" || client.Sent["message_id"] != float64(2) {
+		t.Errorf("sent %v", client.Sent)
+	}
+
+	client.Response = []byte(`{"@type":"messageSenderUser","user_id":7}`)
+	if sender, err := client.GetChatSender(1); err != nil || sender.(*tdlib.MessageSenderUser).UserID != 7 {
+		t.Errorf("got %v, %v", sender, err)
+	}
+
+	client.Response = []byte(`{"@type":"messageSenderBot","bot_id":1}`)
+	var unknown tdlib.ErrUnknownConstructor
+	if _, err := client.GetChatSender(1); !errors.As(err, &unknown) || unknown.Type != "messageSenderBot" {
+		t.Errorf("got %v, want ErrUnknownConstructor", err)
+	}
+
+	client.Response = []byte(`{"@type":"error","code":404,"message":"Not Found"}`)
+	var requestError tdlib.RequestError
+	if _, err := client.GetChatSender(1); !errors.As(err, &requestError) || requestError.Code != 404 {
+		t.Errorf("got %v, want a RequestError", err)
+	}
+
+	client.Response = []byte(`{"code":404}`)
+	if _, err := client.GetMessage(1, 2); !errors.As(err, new(tdlib.ErrMissingType)) {
+		t.Errorf("got %v, want ErrMissingType", err)
+	}
+}
+
+func TestUnmarshalInterfaceFields(t *testing.T) {
+	var message tdlib.Message
+	err := json.Unmarshal([]byte(`{"@type":"message","id":1,"sender":{"@type":"messageSenderUser","user_id":7}}`), &message)
+	if err != nil || message.Sender.(*tdlib.MessageSenderUser).UserID != 7 {
+		t.Errorf("got %v, %v", message, err)
+	}
+
+	err = json.Unmarshal([]byte(`{"@type":"message","id":1,"sender":{"@type":"messageSenderBot"}}`), &message)
+	var unknown tdlib.ErrUnknownConstructor
+	if !errors.As(err, &unknown) || err.Error() != "Message.Sender: unknown constructor messageSenderBot" {
+		t.Errorf("got %v, want ErrUnknownConstructor", err)
+	}
+
+	// a null interface field is left nil
+	if err := json.Unmarshal([]byte(`{"@type":"message","id":1,"sender":null}`), &message); err != nil || message.Sender != nil {
+		t.Errorf("got %v, %v", message.Sender, err)
+	}
+}
+
+type foreignSender struct{}
+
+func (foreignSender) GetMessageSenderEnum() tdlib.MessageSenderEnum {
+	return "foreign"
+}
+
+func TestBinaryErrors(t *testing.T) {
+	message := tdlib.Message{Sender: foreignSender{}, Content: tdlib.NewMessageText("x", 1)}
+	if err := tdlib.NewBuffer(nil).PutObject(&message); err == nil || err.Error() != "Message.Sender does not implement TLObject" {
+		t.Errorf("got %v", err)
+	}
+
+	// the ID of the message, then an unknown constructor for its sender
+	b := tdlib.NewBuffer(make([]byte, 8))
+	b.PutUint32(0xdeadbeef)
+	var unknown tdlib.ErrUnknownConstructor
+	if err := message.DecodeTL(b); !errors.As(err, &unknown) || unknown.ID != 0xdeadbeef {
+		t.Errorf("got %v, want ErrUnknownConstructor", err)
+	}
+}
+
+// BenchmarkDecodeTwice decodes responses as methods did before MessageTypeOf: to UpdateData for '@type', then again
+func BenchmarkDecodeTwice(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		var data tdlib.UpdateData
+		if err := json.Unmarshal(payload, &data); err != nil || data["@type"] == "error" {
+			b.Fatal(err)
+		}
+		var message tdlib.Message
+		if err := json.Unmarshal(payload, &message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeOnce scans '@type' with MessageTypeOf, and decodes once
+func BenchmarkDecodeOnce(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		messageType, err := tdlib.MessageTypeOf(payload)
+		if err != nil || messageType == "error" {
+			b.Fatal(err)
+		}
+		var message tdlib.Message
+		if err := json.Unmarshal(payload, &message); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMessageTypeOfLate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := tdlib.MessageTypeOf(late); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
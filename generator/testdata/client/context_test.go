@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestContext runs against the methods of the testSchema of the generator, generated with Options.Context
+func TestContext(t *testing.T) {
+	client := &Client{Response: []byte(`{"@type":"text","text":"hi"}`)}
+	if text, err := client.GetText(context.Background(), "hello"); err != nil || text.Text != "hi" {
+		t.Errorf("got %v, %v", text, err)
+	}
+	if client.Sent["@type"] != "getText" || client.Sent["text"] != "hello" {
+		t.Errorf("sent %v", client.Sent)
+	}
+
+	// the context is passed on to SendAndCatchContext
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.Sent = nil
+	if _, err := client.GetText(ctx, "hello"); !errors.Is(err, context.Canceled) || client.Sent != nil {
+		t.Errorf("got %v, sent %v, want context.Canceled before sending", err, client.Sent)
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"example.com/td/tdlib"
+)
+
+// TestUnknownTypes runs against the methods of the unknownTypesSchema of the generator, generated with
+// Options.UnknownTypes
+func TestUnknownTypes(t *testing.T) {
+	video := `{"@type":"contentVideo","duration":1}`
+	client := &Client{Response: []byte(video)}
+	content, err := client.GetContent()
+	if unknown, ok := content.(*tdlib.UnknownContent); err != nil || !ok || unknown.Type != "contentVideo" || string(unknown.Raw) != video {
+		t.Fatalf("got %#v, %v, want an UnknownContent", content, err)
+	}
+	if content.GetContentEnum() != "contentVideo" {
+		t.Errorf("got enum %s", content.GetContentEnum())
+	}
+
+	// known types are still decoded to their struct
+	client.Response = []byte(`{"@type":"contentText","text":"hi"}`)
+	if content, err := client.GetContent(); err != nil || content.(*tdlib.ContentText).Text != "hi" {
+		t.Errorf("got %#v, %v", content, err)
+	}
+
+	// fields too, and they are encoded back as they were
+	data := `{"@type":"message","id":1,"content":` + video + `}`
+	var message tdlib.Message
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatal(err)
+	}
+	if unknown, ok := message.Content.(*tdlib.UnknownContent); !ok || unknown.Type != "contentVideo" {
+		t.Errorf("got %#v, want an UnknownContent", message.Content)
+	}
+	if encoded, err := json.Marshal(message.Content); err != nil || string(encoded) != video {
+		t.Errorf("got %s, %v, want %s", encoded, err, video)
+	}
+}
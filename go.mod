@@ -1,8 +1,5 @@
 module github.com/Arman92/go-tl-parser
 
-go 1.15
+go 1.16
 
-require (
-	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d
-	golang.org/x/tools v0.1.2 // indirect
-)
+require golang.org/x/tools v0.1.2 // indirect
@@ -2,45 +2,181 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"strings"
 
 	"github.com/Arman92/go-tl-parser/generator"
+	"github.com/Arman92/go-tl-parser/naming"
 	"github.com/Arman92/go-tl-parser/tlparser"
 )
 
 type config struct {
+	file             string
 	version          string
 	packageName      string
+	methodsPackage   string
+	backend          string
 	typesOutputDir   string
 	methodsOutputDir string
 	basePackageUri   string
+	binaryCodec      bool
+	context          bool
+	unknownTypes     bool
+	clean            bool
+	force            bool
+	check            bool
+	format           string
+	output           string
+	naming           string
+	templates        string
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			runFormat(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "apidiff":
+			runAPIDiff(os.Args[2:])
+			return
+		case "validate":
+			runValidate(os.Args[2:])
+			return
+		}
+	}
+
 	var config config
 
+	flag.StringVar(&config.file, "file", "", "schema to read: a .tl file, a directory containing td_api.tl, a URL or - for stdin (defaults to td_api.tl of -version)")
 	flag.StringVar(&config.version, "version", "v1.7.0", "TDLib version")
 	flag.StringVar(&config.typesOutputDir, "typesOutputDir", "../go-tdlib/tdlib/", "output directory")
 	flag.StringVar(&config.methodsOutputDir, "methodsOutputDir", "../go-tdlib/client/", "output directory")
 	flag.StringVar(&config.basePackageUri, "basePackageUri", "github.com/Arman92/go-tdlib", "base package uri")
 	flag.StringVar(&config.packageName, "package", "tdlib", "package name")
+	flag.StringVar(&config.methodsPackage, "methodsPackage", "client", "package name of the methods")
+	flag.StringVar(&config.backend, "backend", "go", "backend generating the code, one of "+strings.Join(generator.BackendNames(), ", "))
+	flag.BoolVar(&config.binaryCodec, "binary", false, "also generate TL binary (MTProto) EncodeTL/DecodeTL methods")
+	flag.BoolVar(&config.context, "context", false, "give methods a leading ctx context.Context parameter, sent with client.SendAndCatchContext")
+	flag.BoolVar(&config.unknownTypes, "unknownTypes", false, "decode objects of unknown types to an UnknownX type per interface X rather than failing")
+	flag.BoolVar(&config.clean, "clean", true, "remove the generated files of typesOutputDir that are not generated anymore")
+	flag.BoolVar(&config.force, "force", false, "overwrite files at the path of generated files even if they were not generated")
+	flag.BoolVar(&config.check, "check", false, "do not write anything, print the differences between the generated code and the files on disk and fail if there are any")
+	flag.StringVar(&config.format, "format", "go", "output format: go for Go structs and methods, json for the parsed schema")
+	flag.StringVar(&config.output, "output", "-", "file the json schema is written to, - for stdout")
+	flag.StringVar(&config.templates, "templates", "", "directory of *.tmpl templates overriding the default ones, such as method.tmpl")
+	flag.StringVar(&config.naming, "naming", "", "JSON file of naming rules: initialisms, word spellings and identifier overrides")
 
 	flag.Parse()
+	if flag.NArg() > 0 {
+		// such as 'file=./schema.tl', as earlier versions took
+		fmt.Fprintf(flag.CommandLine.Output(), "unexpected argument %q, options are flags such as -file=./td_api.tl\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	location := config.file
+	if location == "" {
+		location = fmt.Sprintf(tdlibSchemaURL, config.version)
+	}
+	if config.format != "go" && config.format != "json" {
+		log.Fatalf("unknown format %q, expected go or json", config.format)
+	}
+	backend, ok := generator.LookupBackend(config.backend)
+	if !ok {
+		log.Fatalf("unknown backend %q, expected one of %s", config.backend, strings.Join(generator.BackendNames(), ", "))
+	}
+
+	schema, name := parseSchema(location)
 
-	resp, err := http.Get("https://raw.githubusercontent.com/tdlib/td/" + config.version + "/td/generate/scheme/td_api.tl")
+	switch config.format {
+	case "go":
+		options := generator.Options{
+			Backend:            backend,
+			BasePackageURI:     config.basePackageUri,
+			PackageName:        config.packageName,
+			MethodsPackageName: config.methodsPackage,
+			TypesOutputDir:     config.typesOutputDir,
+			MethodsOutputDir:   config.methodsOutputDir,
+			BinaryCodec:        config.binaryCodec,
+			Context:            config.context,
+			UnknownTypes:       config.unknownTypes,
+			Clean:              config.clean,
+			Force:              config.force,
+			Naming:             loadNaming(config.naming),
+		}
+		if config.templates != "" {
+			options.Templates = os.DirFS(config.templates)
+		}
+
+		if config.check {
+			checkCode(schema, options)
+			return
+		}
+
+		if err := generator.GenerateCode(schema, options); err != nil {
+			log.Fatal(err)
+		}
+
+	case "json":
+		if err := exportJSON(schema, name, config.output); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// checkCode prints a unified diff of each generated file that differs from the one on disk,
+// and exits with status 1 if there are any
+func checkCode(schema *tlparser.TlSchema, options generator.Options) {
+	drifts, err := generator.CheckCode(schema, options)
 	if err != nil {
-		log.Fatalf("http.Get error: %s", err)
-		return
+		log.Fatal(err)
 	}
-	defer resp.Body.Close()
 
-	schema, err := tlparser.ParseInputSchema(resp.Body)
+	for _, drift := range drifts {
+		fmt.Print(unifiedDiff(drift.Path, drift.Path+" (generated)", drift.OnDisk, drift.Generated))
+	}
+
+	if len(drifts) > 0 {
+		log.Printf("%d generated files are out of date", len(drifts))
+		os.Exit(1)
+	}
+}
+
+// exportJSON writes schema as a tlparser.SchemaDocument to output, "-" being stdout
+func exportJSON(schema *tlparser.TlSchema, source, output string) error {
+	if output == "-" {
+		return tlparser.WriteJSON(os.Stdout, source, schema)
+	}
+
+	file, err := os.Create(output)
 	if err != nil {
-		log.Fatalf("schema parse error: %s", err)
-		return
+		return err
+	}
+
+	if err := tlparser.WriteJSON(file, source, schema); err != nil {
+		file.Close()
+		return err
 	}
 
-	generator.GenerateCode(schema, config.basePackageUri, config.packageName, config.typesOutputDir, config.methodsOutputDir)
+	return file.Close()
+}
+
+// loadNaming returns the Namer of the naming.Config in the JSON file at path, nil for the default one if path is empty
+func loadNaming(path string) *naming.Namer {
+	if path == "" {
+		return nil
+	}
+
+	namingConfig, err := naming.LoadConfig(path)
+	if err != nil {
+		log.Fatalf("failed to read naming rules: %s", err)
+	}
 
+	return naming.New(namingConfig)
 }
@@ -0,0 +1,276 @@
+// Package naming turns the names of a .tl schema, such as 'chat_id' or 'inputMessageText',
+// into Go identifiers, such as 'ChatID' or 'InputMessageText'.
+package naming
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"unicode"
+)
+
+// DefaultInitialisms are the words written in upper case unless Config says otherwise
+var DefaultInitialisms = []string{"API", "URL", "ID", "TTL", "HTML", "URI", "IP", "UDP"}
+
+// Config customizes a Namer, it is usually loaded from a JSON file with LoadConfig
+type Config struct {
+	// Initialisms are words spelled as given whatever their case in the schema, such as "ID" or "IPv6".
+	// They are added to DefaultInitialisms, unless NoDefaultInitialisms is set.
+	Initialisms          []string `json:"initialisms"`
+	NoDefaultInitialisms bool     `json:"no_default_initialisms"`
+
+	// Words maps words, as split by SplitWords, to their spelling, e.g. "Ipv6" to "IPv6" or "Tdlib" to "TDLib"
+	Words map[string]string `json:"words"`
+
+	// Identifiers maps whole exported identifiers to the ones to use instead, e.g. "TdlibParameters" to "Parameters".
+	// Unexported identifiers, such as argument names, are derived from the replacement.
+	Identifiers map[string]string `json:"identifiers"`
+
+	// Types, Fields, Methods and Params rename single TL names, e.g. "tdlibParameters" to "Parameters",
+	// Words and Identifiers do not apply to the new names. Keys of Fields and Params are either a field name, or a class or function name and a field
+	// name such as "message.id", which only renames the field of that class or function.
+	Types   map[string]string `json:"types"`   // names of classes and interfaces
+	Fields  map[string]string `json:"fields"`  // struct fields of class fields
+	Methods map[string]string `json:"methods"` // methods of functions
+	Params  map[string]string `json:"params"`  // parameters of NewX constructors and methods
+}
+
+// LoadConfig reads a Config from the JSON file at path
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+
+	return config, err
+}
+
+// Namer builds Go identifiers from schema names
+type Namer struct {
+	words       map[string]string // lower case word to its spelling
+	identifiers map[string]string
+
+	types, fields, methods, params map[string]string
+}
+
+// Default is the Namer of an empty Config
+var Default = New(Config{})
+
+// New returns a Namer following config
+func New(config Config) *Namer {
+	namer := &Namer{
+		words:       map[string]string{},
+		identifiers: copyMap(config.Identifiers),
+		types:       copyMap(config.Types),
+		fields:      copyMap(config.Fields),
+		methods:     copyMap(config.Methods),
+		params:      copyMap(config.Params),
+	}
+
+	initialisms := config.Initialisms
+	if !config.NoDefaultInitialisms {
+		initialisms = append(append([]string{}, DefaultInitialisms...), initialisms...)
+	}
+	for _, initialism := range initialisms {
+		namer.words[strings.ToLower(initialism)] = initialism
+	}
+	for word, spelling := range config.Words {
+		namer.words[strings.ToLower(word)] = spelling
+	}
+
+	return namer
+}
+
+// Exported returns the exported Go identifier of name, e.g. 'ChatID' for 'chat_id' or 'chatId'
+func (namer *Namer) Exported(name string) string {
+	identifier := namer.join(SplitWords(name))
+	if replacement, ok := namer.identifiers[identifier]; ok {
+		return replacement
+	}
+
+	return identifier
+}
+
+// Type returns the name of the Go type of the class or interface tlName. A type can be referred to with either
+// case of its first letter in TL, e.g. 'chat' or 'Chat', and both find the same renaming.
+func (namer *Namer) Type(tlName string) string {
+	if name, ok := namer.types[tlName]; ok {
+		return name
+	}
+	if name, ok := namer.types[swapFirstCase(tlName)]; ok {
+		return name
+	}
+
+	return namer.Exported(tlName)
+}
+
+// Field returns the name of the struct field of the field tlName of class owner
+func (namer *Namer) Field(owner, tlName string) string {
+	if name, ok := lookup(namer.fields, owner, tlName); ok {
+		return name
+	}
+
+	return namer.Exported(tlName)
+}
+
+// Method returns the name of the method of the function tlName
+func (namer *Namer) Method(tlName string) string {
+	if name, ok := namer.methods[tlName]; ok {
+		return name
+	}
+
+	return namer.Exported(tlName)
+}
+
+// Param returns the name of the parameter for the field tlName of owner, a class or a function
+func (namer *Namer) Param(owner, tlName string) string {
+	if name, ok := lookup(namer.params, owner, tlName); ok {
+		return name
+	}
+
+	return namer.Unexported(tlName)
+}
+
+// lookup finds the renaming of the field tlName of owner in renames, "owner.tlName" taking precedence over "tlName"
+func lookup(renames map[string]string, owner, tlName string) (string, bool) {
+	if name, ok := renames[owner+"."+tlName]; ok {
+		return name, true
+	}
+	name, ok := renames[tlName]
+
+	return name, ok
+}
+
+// join returns the exported identifier made of words, before Config.Identifiers are applied
+func (namer *Namer) join(words []string) string {
+	var identifier strings.Builder
+	for _, word := range words {
+		identifier.WriteString(namer.word(word))
+	}
+
+	return identifier.String()
+}
+
+// Unexported returns the unexported Go identifier of name, e.g. 'chatID' for 'chat_id' or 'ChatId'.
+// A leading initialism is written in lower case, e.g. 'urlText' for 'url_text'.
+func (namer *Namer) Unexported(name string) string {
+	exported := namer.Exported(name)
+	if exported == "" {
+		return ""
+	}
+
+	words := SplitWords(name)
+	if _, ok := namer.identifiers[namer.join(words)]; ok {
+		// overridden, only the replacement is known
+		words = SplitWords(exported)
+	}
+
+	first := namer.word(words[0])
+	if namer.isSpelled(words[0]) && strings.HasPrefix(exported, first) {
+		// such as 'URL', 'IDs' or 'IPv6'
+		return strings.ToLower(first) + exported[len(first):]
+	}
+
+	return string(unicode.ToLower(rune(exported[0]))) + exported[1:]
+}
+
+// isSpelled reports whether word has a configured spelling, or is the plural of an initialism
+func (namer *Namer) isSpelled(word string) bool {
+	lower := strings.ToLower(word)
+	if _, ok := namer.words[lower]; ok {
+		return true
+	}
+	spelling, ok := namer.words[strings.TrimSuffix(lower, "s")]
+
+	return ok && strings.HasSuffix(lower, "s") && isUpper(spelling)
+}
+
+// word returns the spelling of word in identifiers: its configured spelling, or the word with an upper case first letter.
+// Plurals of initialisms end with a lower case 's', e.g. 'IDs'.
+func (namer *Namer) word(word string) string {
+	lower := strings.ToLower(word)
+	if spelling, ok := namer.words[lower]; ok {
+		return spelling
+	}
+	if strings.HasSuffix(lower, "s") {
+		if spelling, ok := namer.words[strings.TrimSuffix(lower, "s")]; ok && isUpper(spelling) {
+			return spelling + "s"
+		}
+	}
+
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+// SplitWords splits a name into words at underscores and case changes, digits belong to the word they follow:
+// 'chat_id' gives 'chat', 'id'; 'inputMessageText' gives 'input', 'Message', 'Text';
+// 'HTMLText' gives 'HTML', 'Text' and 'ipv6Address' gives 'ipv6', 'Address'.
+// Plural initialisms are one word: 'chatIDs' gives 'chat', 'IDs'.
+func SplitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		boundary := i == len(runes) || runes[i] == '_' || runes[i] == '-' || runes[i] == '.'
+		if !boundary && i > start && unicode.IsUpper(runes[i]) {
+			previous := runes[i-1]
+			// 'aB' starts a word at B, and so does 'ABc' at B, but for the plural 'ABs'
+			boundary = unicode.IsLower(previous) || unicode.IsDigit(previous) ||
+				(unicode.IsUpper(previous) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isPluralEnd(runes, i+1))
+			if boundary {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+			continue
+		}
+
+		if boundary {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+		}
+	}
+
+	return words
+}
+
+// isPluralEnd reports whether runes[i] is an 's' ending a word
+func isPluralEnd(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || !unicode.IsLower(runes[i+1]))
+}
+
+func swapFirstCase(str string) string {
+	runes := []rune(str)
+	if len(runes) == 0 {
+		return str
+	}
+
+	if unicode.IsUpper(runes[0]) {
+		runes[0] = unicode.ToLower(runes[0])
+	} else {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+
+	return string(runes)
+}
+
+func copyMap(m map[string]string) map[string]string {
+	copied := map[string]string{}
+	for key, value := range m {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+func isUpper(str string) bool {
+	return strings.ToUpper(str) == str
+}
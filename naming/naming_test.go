@@ -0,0 +1,67 @@
+package naming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		"chat_id":          {"chat", "id"},
+		"inputMessageText": {"input", "Message", "Text"},
+		"HTMLText":         {"HTML", "Text"},
+		"ipv6Address":      {"ipv6", "Address"},
+		"IDs":              {"IDs"},
+		"chatIDs":          {"chat", "IDs"},
+		"IDsList":          {"IDs", "List"},
+		"URLSection":       {"URL", "Section"},
+		"Identity":         {"Identity"},
+		"messages.getChat": {"messages", "get", "Chat"},
+	}
+	for name, want := range tests {
+		if words := SplitWords(name); !reflect.DeepEqual(words, want) {
+			t.Errorf("%s: got %q, want %q", name, words, want)
+		}
+	}
+}
+
+func TestNamer(t *testing.T) {
+	namer := New(Config{
+		Words:       map[string]string{"ipv6": "IPv6"},
+		Identifiers: map[string]string{"TdlibParameters": "Parameters", "MessageIDs": "IDs"},
+		Types:       map[string]string{"chatPhoto": "Photo"},
+		Fields:      map[string]string{"message.id": "MessageID", "ttl": "TimeToLive"},
+		Methods:     map[string]string{"getMe": "Me"},
+		Params:      map[string]string{"chat_id": "chat"},
+	})
+
+	tests := []struct{ got, want string }{
+		{namer.Exported("chat_id"), "ChatID"},
+		{namer.Exported("user_ids"), "UserIDs"},
+		{namer.Unexported("ids"), "ids"},
+		{namer.Exported("ipv6_address"), "IPv6Address"},
+		{namer.Unexported("ipv6_address"), "ipv6Address"},
+		{namer.Exported("identity"), "Identity"},
+		{namer.Unexported("identity"), "identity"},
+		{namer.Unexported("url_text"), "urlText"},
+
+		// overrides
+		{namer.Exported("tdlibParameters"), "Parameters"},
+		{namer.Unexported("tdlib_parameters"), "parameters"},
+		{namer.Exported("message_ids"), "IDs"},
+		{namer.Unexported("message_ids"), "ids"},
+		{namer.Type("chatPhoto"), "Photo"},
+		{namer.Type("ChatPhoto"), "Photo"},
+		{namer.Field("message", "id"), "MessageID"},
+		{namer.Field("chat", "id"), "ID"},
+		{namer.Field("chat", "ttl"), "TimeToLive"},
+		{namer.Method("getMe"), "Me"},
+		{namer.Param("getChat", "chat_id"), "chat"},
+		{namer.Param("getChat", "user_id"), "userID"},
+	}
+	for i, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%d: got %s, want %s", i, test.got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const tdlibSchemaURL = "https://raw.githubusercontent.com/tdlib/td/%s/td/generate/scheme/td_api.tl"
+
+// schemaCandidates are the paths, relative to a directory, where a schema is looked for.
+// The second one matches a checkout of the TDLib repository (or a fork of it).
+var schemaCandidates = []string{
+	"td_api.tl",
+	filepath.Join("td", "generate", "scheme", "td_api.tl"),
+}
+
+// schemaSource is an opened .tl schema along with the name it was resolved to
+type schemaSource struct {
+	io.ReadCloser
+	Name string
+}
+
+// openSchema opens the schema found at location, which may be "-" for stdin,
+// an http(s) URL, a .tl file or a directory containing one.
+func openSchema(location string) (*schemaSource, error) {
+	switch {
+	case location == "-":
+		return &schemaSource{ReadCloser: ioutil.NopCloser(os.Stdin), Name: "<stdin>"}, nil
+
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		return openSchemaURL(location)
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		location, err = findSchemaInDir(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.Open(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schemaSource{ReadCloser: file, Name: location}, nil
+}
+
+func openSchemaURL(url string) (*schemaSource, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return &schemaSource{ReadCloser: resp.Body, Name: url}, nil
+}
+
+// findSchemaInDir returns the schema file of dir, which is either one of
+// schemaCandidates or the only .tl file in it.
+func findSchemaInDir(dir string) (string, error) {
+	for _, candidate := range schemaCandidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tl"))
+	if err != nil {
+		return "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no .tl schema found in %s", dir)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("multiple .tl schemas found in %s, pick one of: %s", dir, strings.Join(matches, ", "))
+	}
+}
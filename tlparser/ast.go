@@ -0,0 +1,151 @@
+package tlparser
+
+import "fmt"
+
+// Position is a location in a .tl file
+type Position struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`   // 1-based line number
+	Column int    `json:"column"` // 1-based byte offset in the line
+}
+
+// IsValid reports whether the position is set
+func (pos Position) IsValid() bool {
+	return pos.Line > 0
+}
+
+func (pos Position) String() string {
+	file := pos.File
+	if file == "" {
+		file = "<input>"
+	}
+
+	return fmt.Sprintf("%s:%d:%d", file, pos.Line, pos.Column)
+}
+
+// Node is implemented by all the nodes of the syntax tree
+type Node interface {
+	Pos() Position // position of the first character of the node
+	End() Position // position of the character right after the node
+}
+
+// Span is the part of the file a node was parsed from, it implements Node
+type Span struct {
+	From Position `json:"from"`
+	To   Position `json:"to"`
+}
+
+// Pos returns the position of the first character of the node
+func (span Span) Pos() Position {
+	return span.From
+}
+
+// End returns the position of the character right after the node
+func (span Span) End() Position {
+	return span.To
+}
+
+// File is the syntax tree of a .tl file
+type File struct {
+	Span
+	Name         string         `json:"name"`
+	Declarations []*Declaration `json:"declarations"` // in the order they appear in the file
+	Comments     []*Comment     `json:"comments"`     // all the comments of the file, documentation included
+}
+
+// DeclarationKind tells what a Declaration declares
+type DeclarationKind int
+
+const (
+	// CombinatorDeclaration is a constructor or a function, 'name field:type = Type;'
+	CombinatorDeclaration DeclarationKind = iota
+	// ClassDeclaration is a documented abstract class, '//@class Name @description ...'
+	ClassDeclaration
+	// SectionDeclaration is a section marker, '---functions---' or '---types---'
+	SectionDeclaration
+)
+
+// Declaration is a top level item of a .tl file
+type Declaration struct {
+	Span
+	Kind DeclarationKind `json:"kind"`
+
+	// Doc is the documentation comment of a combinator, nil if there is none,
+	// or the '//@class' comment of a class declaration.
+	Doc *Comment `json:"doc,omitempty"`
+
+	Combinator *Combinator `json:"combinator,omitempty"` // set for CombinatorDeclaration
+	Section    string      `json:"section,omitempty"`    // set for SectionDeclaration, 'functions' or 'types'
+}
+
+// Comment is a group of consecutive comment lines
+type Comment struct {
+	Span
+	Lines []string  `json:"lines"` // the text of each line, '//' included
+	Tags  []*DocTag `json:"tags"`  // the '@name value' pairs of documentation comments
+}
+
+// Tag returns the value of the first tag called name
+func (comment *Comment) Tag(name string) (string, bool) {
+	if comment == nil {
+		return "", false
+	}
+
+	for _, tag := range comment.Tags {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// DocTag is an '@name value' pair of a documentation comment.
+// Text preceding the first '@' of a comment is a tag with an empty name.
+type DocTag struct {
+	Span
+	Name  string `json:"name"`
+	Value string `json:"value"` // with whitespace collapsed, '//-' continuation lines joined
+}
+
+// Combinator is a 'name#id {X:Type} field:type ... = Type;' declaration
+type Combinator struct {
+	Span
+	Name       string    `json:"name"`
+	ID         uint32    `json:"id,omitempty"` // the explicit constructor ID, 0 if none
+	TypeParams []*Field  `json:"type_params,omitempty"`
+	Fields     []*Field  `json:"fields"`
+	Result     *TypeExpr `json:"result"`
+	Builtin    bool      `json:"builtin,omitempty"` // '?' declares a built-in type, as in 'int ? = Int;'
+}
+
+// Field is a 'name:type' or 'name:flags.N?type' argument of a combinator,
+// or an anonymous one such as '#' or '[ t ]'
+type Field struct {
+	Span
+	Name      string    `json:"name,omitempty"`
+	FlagField string    `json:"flag_field,omitempty"` // set for conditional fields
+	FlagBit   int       `json:"flag_bit,omitempty"`
+	Type      *TypeExpr `json:"type"`
+}
+
+// Condition returns the 'flags.N' condition of the field, or "" if it has none
+func (field *Field) Condition() string {
+	if field.FlagField == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s.%d", field.FlagField, field.FlagBit)
+}
+
+// TypeExpr is a type, such as 'int53', 'vector<int53>', '%Message', '!X' or 'Vector t'
+type TypeExpr struct {
+	Span
+	Text         string      `json:"text"` // as written, with tokens separated by single spaces
+	Name         string      `json:"name"` // e.g. 'vector' for 'vector<int53>', empty for repetitions
+	Args         []*TypeExpr `json:"args,omitempty"`
+	Bare         bool        `json:"bare,omitempty"`         // '%Type'
+	Bang         bool        `json:"bang,omitempty"`         // '!X'
+	Repetition   bool        `json:"repetition,omitempty"`   // '[ t ]', Args holds the repeated types
+	Multiplicity string      `json:"multiplicity,omitempty"` // 'N' of 'N*[ t ]'
+}
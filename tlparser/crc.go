@@ -0,0 +1,74 @@
+package tlparser
+
+import (
+	"hash/crc32"
+	"regexp"
+	"strings"
+)
+
+// trueFlagPattern matches 'name:flags.N?true' fields, which are left out of the constructor ID
+var trueFlagPattern = regexp.MustCompile(` \w+:\w+\.\d+\?true`)
+
+// bareTypePattern matches the '%' prefix of bare types, '%Message' is written as 'message'
+var bareTypePattern = regexp.MustCompile(`%\w`)
+
+// ConstructorID returns the explicit constructor ID if there is one, the computed one otherwise
+func (classInfo *ClassInfo) ConstructorID() uint32 {
+	if classInfo.ID != 0 {
+		return classInfo.ID
+	}
+
+	return classInfo.CRC32
+}
+
+// ConstructorID returns the explicit constructor ID if there is one, the computed one otherwise
+func (functionInfo *FunctionInfo) ConstructorID() uint32 {
+	if functionInfo.ID != 0 {
+		return functionInfo.ID
+	}
+
+	return functionInfo.CRC32
+}
+
+// combinatorText returns the normalized text of a combinator, as used to compute its ID:
+// the declaration without the ID and the ';', with one space between tokens.
+func combinatorText(name string, typeParams []TypeParam, properties []Property, resultType string) string {
+	parts := []string{name}
+
+	for _, typeParam := range typeParams {
+		parts = append(parts, "{"+typeParam.Name+":"+typeParam.Type+"}")
+	}
+
+	for _, property := range properties {
+		switch {
+		case property.Name == "":
+			parts = append(parts, property.Type)
+		case property.IsConditional():
+			parts = append(parts, property.Name+":"+property.Condition+"?"+property.Type)
+		default:
+			parts = append(parts, property.Name+":"+property.Type)
+		}
+	}
+
+	parts = append(parts, "=", resultType)
+
+	return strings.Join(parts, " ")
+}
+
+// computeConstructorID computes the CRC32 of the combinator text the way Telegram's tooling does:
+// bytes is written as string, vector<T> as 'vector T', %Type as type, type parameters
+// lose their braces and 'flags.N?true' fields are removed.
+func computeConstructorID(text string) uint32 {
+	text = strings.Replace(text, ":bytes ", ":string ", -1)
+	text = strings.Replace(text, "?bytes ", "?string ", -1)
+	text = strings.Replace(text, "<", " ", -1)
+	text = strings.Replace(text, ">", "", -1)
+	text = strings.Replace(text, "{", "", -1)
+	text = strings.Replace(text, "}", "", -1)
+	text = trueFlagPattern.ReplaceAllString(text, "")
+	text = bareTypePattern.ReplaceAllStringFunc(text, func(bare string) string {
+		return strings.ToLower(bare[1:])
+	})
+
+	return crc32.ChecksumIEEE([]byte(text))
+}
@@ -0,0 +1,48 @@
+package tlparser
+
+import "testing"
+
+func TestConstructorIDs(t *testing.T) {
+	// the IDs of Telegram's api.tl, and those of TDLib's generated td_api.h for td_api.tl
+	tests := map[string]map[string]uint32{
+		"api.tl": {
+			"boolTrue":               0x997275b5,
+			"boolFalse":              0xbc799737,
+			"true":                   0x3fedd339,
+			"vector":                 0x1cb5c415,
+			"error":                  0xc4b9f9bb,
+			"inputPeerChat":          0x35a95cb9,
+			"msg_container":          0x73f1f8dc, // %Message
+			"resPQ":                  0x05162463, // int128 and Vector<long>
+			"updateShortMessage":     0x313bc7f8, // flags.N?true fields
+			"invokeWithLayer":        0xda9b0d0d, // {X:Type} and !X
+			"users.getUsers":         0x0d91a548,
+			"account.registerDevice": 0xec86017a, // bytes
+			"messages.sendMessage":   0x1cc20387,
+		},
+		"td_api.tl": {
+			"error": 0x9bdd8f1a,
+			"ok":    0xd4edbe69,
+		},
+	}
+
+	for file, ids := range tests {
+		schema := parseTestFile(t, file)
+		crcs := map[string]uint32{}
+		for _, builtin := range schema.Builtins {
+			crcs[builtin.Name] = builtin.CRC32
+		}
+		for _, class := range schema.Classes {
+			crcs[class.Name] = class.CRC32
+		}
+		for _, function := range schema.Functions {
+			crcs[function.Name] = function.CRC32
+		}
+
+		for name, id := range ids {
+			if crc, ok := crcs[name]; !ok || crc != id {
+				t.Errorf("%s: %s: got #%08x, want #%08x", file, name, crc, id)
+			}
+		}
+	}
+}
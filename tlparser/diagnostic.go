@@ -0,0 +1,47 @@
+package tlparser
+
+import "fmt"
+
+// Severity tells how serious a Diagnostic is
+type Severity int
+
+const (
+	// SeverityWarning is for schemas that can be used, but are probably wrong
+	SeverityWarning Severity = iota
+	// SeverityError is for schemas that would produce broken code
+	SeverityError
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// MarshalText encodes the severity by its name
+func (severity Severity) MarshalText() ([]byte, error) {
+	return []byte(severity.String()), nil
+}
+
+// Diagnostic is a problem found in a schema that did not prevent parsing it
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d *Diagnostic) String() string {
+	file := d.File
+	if file == "" {
+		file = "<input>"
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", file, d.Line, d.Column, d.Severity, d.Message)
+}
@@ -0,0 +1,263 @@
+package tlparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangeKind tells what changed between two schemas
+type ChangeKind string
+
+// Kinds of Change
+const (
+	ClassAdded        ChangeKind = "class_added"
+	ClassRemoved      ChangeKind = "class_removed"
+	ClassRenamed      ChangeKind = "class_renamed"
+	FunctionAdded     ChangeKind = "function_added"
+	FunctionRemoved   ChangeKind = "function_removed"
+	FunctionRenamed   ChangeKind = "function_renamed"
+	InterfaceAdded    ChangeKind = "interface_added"
+	InterfaceRemoved  ChangeKind = "interface_removed"
+	FieldAdded        ChangeKind = "field_added"
+	FieldRemoved      ChangeKind = "field_removed"
+	FieldTypeChanged  ChangeKind = "field_type_changed"
+	ReturnTypeChanged ChangeKind = "return_type_changed"
+	InterfaceChanged  ChangeKind = "interface_changed" // a class now belongs to another interface
+	IDChanged         ChangeKind = "id_changed"        // the explicit constructor ID, 'name#id', changed
+)
+
+// Change is a difference between two schemas, see Diff
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+
+	// Name is the class, function or interface the change is about, its new name for renames
+	Name    string `json:"name"`
+	OldName string `json:"old_name,omitempty"` // set for renames
+	Field   string `json:"field,omitempty"`    // set for changes of fields
+
+	// OldType and NewType are the types of changed fields, the return types of changed functions and the
+	// interfaces of classes that changed interface. Only NewType is set for added classes, functions and fields,
+	// only OldType for removed ones.
+	OldType string `json:"old_type,omitempty"`
+	NewType string `json:"new_type,omitempty"`
+
+	// OldID and NewID are set for IDChanged
+	OldID uint32 `json:"old_id,omitempty"`
+	NewID uint32 `json:"new_id,omitempty"`
+}
+
+func (change *Change) String() string {
+	switch change.Kind {
+	case ClassAdded, FunctionAdded:
+		return fmt.Sprintf("added %s %s: %s", change.subject(), change.Name, change.NewType)
+	case ClassRemoved, FunctionRemoved:
+		return fmt.Sprintf("removed %s %s: %s", change.subject(), change.Name, change.OldType)
+	case ClassRenamed, FunctionRenamed:
+		return fmt.Sprintf("renamed %s %s to %s", change.subject(), change.OldName, change.Name)
+	case InterfaceAdded:
+		return fmt.Sprintf("added interface %s", change.Name)
+	case InterfaceRemoved:
+		return fmt.Sprintf("removed interface %s", change.Name)
+	case FieldAdded:
+		return fmt.Sprintf("%s: added field %s:%s", change.Name, change.Field, change.NewType)
+	case FieldRemoved:
+		return fmt.Sprintf("%s: removed field %s:%s", change.Name, change.Field, change.OldType)
+	case FieldTypeChanged:
+		return fmt.Sprintf("%s: field %s changed type from %s to %s", change.Name, change.Field, change.OldType, change.NewType)
+	case ReturnTypeChanged:
+		return fmt.Sprintf("%s: return type changed from %s to %s", change.Name, change.OldType, change.NewType)
+	case InterfaceChanged:
+		return fmt.Sprintf("%s: moved from interface %s to %s", change.Name, change.OldType, change.NewType)
+	case IDChanged:
+		return fmt.Sprintf("%s: constructor ID changed from #%08x to #%08x", change.Name, change.OldID, change.NewID)
+	}
+
+	return fmt.Sprintf("%s %s", change.Kind, change.Name)
+}
+
+func (change *Change) subject() string {
+	if strings.HasPrefix(string(change.Kind), "function") {
+		return "function"
+	}
+
+	return "class"
+}
+
+// combinator is what Diff compares of classes and functions
+type combinator struct {
+	name       string
+	id         uint32 // explicit constructor ID, 0 if none
+	properties []Property
+	resultType string // RootName of classes, ReturnType of functions
+}
+
+// signature identifies a combinator regardless of its name, to find renamed ones
+func (c *combinator) signature() string {
+	fields := make([]string, 0, len(c.properties)+1)
+	for _, property := range c.properties {
+		fields = append(fields, property.Name+":"+propertyType(property))
+	}
+
+	return strings.Join(fields, " ") + " = " + c.resultType
+}
+
+// propertyType returns the type of property as written in the schema, with its condition if any
+func propertyType(property Property) string {
+	if property.IsConditional() {
+		return property.Condition + "?" + property.Type
+	}
+
+	return property.Type
+}
+
+// Diff returns the changes between the old and new versions of a schema: the classes first, then the
+// interfaces and the functions, each in the order of the new schema followed by the removed ones.
+// A class or function whose name changed but not its fields nor type is reported as renamed,
+// provided no other added or removed one has the same fields and type.
+func Diff(old, new *TlSchema) []*Change {
+	var changes []*Change
+
+	oldClasses, newClasses := classCombinators(old), classCombinators(new)
+	changes = append(changes, diffCombinators(oldClasses, newClasses, ClassAdded, ClassRemoved, ClassRenamed, InterfaceChanged)...)
+
+	oldInterfaces := map[string]bool{}
+	for _, interfaceInfo := range old.Interfaces {
+		oldInterfaces[interfaceInfo.Name] = true
+	}
+	newInterfaces := map[string]bool{}
+	for _, interfaceInfo := range new.Interfaces {
+		newInterfaces[interfaceInfo.Name] = true
+		if !oldInterfaces[interfaceInfo.Name] {
+			changes = append(changes, &Change{Kind: InterfaceAdded, Name: interfaceInfo.Name})
+		}
+	}
+	for _, interfaceInfo := range old.Interfaces {
+		if !newInterfaces[interfaceInfo.Name] {
+			changes = append(changes, &Change{Kind: InterfaceRemoved, Name: interfaceInfo.Name})
+		}
+	}
+
+	oldFunctions, newFunctions := functionCombinators(old), functionCombinators(new)
+	changes = append(changes, diffCombinators(oldFunctions, newFunctions, FunctionAdded, FunctionRemoved, FunctionRenamed, ReturnTypeChanged)...)
+
+	return changes
+}
+
+func classCombinators(schema *TlSchema) []*combinator {
+	combinators := make([]*combinator, 0, len(schema.Classes))
+	for _, class := range schema.Classes {
+		combinators = append(combinators, &combinator{name: class.Name, id: class.ID, properties: class.Properties, resultType: class.RootName})
+	}
+
+	return combinators
+}
+
+func functionCombinators(schema *TlSchema) []*combinator {
+	combinators := make([]*combinator, 0, len(schema.Functions))
+	for _, function := range schema.Functions {
+		combinators = append(combinators, &combinator{name: function.Name, id: function.ID, properties: function.Properties, resultType: function.ReturnType})
+	}
+
+	return combinators
+}
+
+// diffCombinators compares the classes or the functions of two schemas, the kinds tell which ones they are
+func diffCombinators(old, new []*combinator, added, removed, renamed, resultChanged ChangeKind) []*Change {
+	oldByName := map[string]*combinator{}
+	for _, c := range old {
+		oldByName[c.name] = c
+	}
+	newByName := map[string]*combinator{}
+	for _, c := range new {
+		newByName[c.name] = c
+	}
+
+	// renames are only detected between combinators whose signature is unique among the added and removed ones
+	removedBySignature := map[string][]*combinator{}
+	for _, c := range old {
+		if newByName[c.name] == nil {
+			removedBySignature[c.signature()] = append(removedBySignature[c.signature()], c)
+		}
+	}
+	addedBySignature := map[string][]*combinator{}
+	for _, c := range new {
+		if oldByName[c.name] == nil {
+			addedBySignature[c.signature()] = append(addedBySignature[c.signature()], c)
+		}
+	}
+	renamedFrom := map[string]*combinator{} // new name to old combinator
+	renamedOld := map[string]bool{}         // old names of the renamed ones
+	for signature, addedOnes := range addedBySignature {
+		removedOnes := removedBySignature[signature]
+		if len(addedOnes) == 1 && len(removedOnes) == 1 {
+			renamedFrom[addedOnes[0].name] = removedOnes[0]
+			renamedOld[removedOnes[0].name] = true
+		}
+	}
+
+	var changes []*Change
+	for _, c := range new {
+		if oldC := renamedFrom[c.name]; oldC != nil {
+			changes = append(changes, &Change{Kind: renamed, Name: c.name, OldName: oldC.name})
+			continue
+		}
+
+		oldC := oldByName[c.name]
+		if oldC == nil {
+			changes = append(changes, &Change{Kind: added, Name: c.name, NewType: c.resultType})
+			continue
+		}
+
+		changes = append(changes, diffFields(oldC, c)...)
+		if oldC.resultType != c.resultType {
+			changes = append(changes, &Change{Kind: resultChanged, Name: c.name, OldType: oldC.resultType, NewType: c.resultType})
+		}
+		if oldC.id != 0 && c.id != 0 && oldC.id != c.id {
+			changes = append(changes, &Change{Kind: IDChanged, Name: c.name, OldID: oldC.id, NewID: c.id})
+		}
+	}
+
+	for _, c := range old {
+		if newByName[c.name] == nil && !renamedOld[c.name] {
+			changes = append(changes, &Change{Kind: removed, Name: c.name, OldType: c.resultType})
+		}
+	}
+
+	return changes
+}
+
+// diffFields compares the fields of two versions of a class or function, anonymous fields are ignored
+func diffFields(old, new *combinator) []*Change {
+	oldFields := map[string]Property{}
+	for _, property := range old.properties {
+		oldFields[property.Name] = property
+	}
+	newFields := map[string]Property{}
+	for _, property := range new.properties {
+		newFields[property.Name] = property
+	}
+
+	var changes []*Change
+	for _, property := range new.properties {
+		if property.Name == "" {
+			continue
+		}
+
+		oldProperty, ok := oldFields[property.Name]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Kind: FieldAdded, Name: new.name, Field: property.Name, NewType: propertyType(property)})
+		case propertyType(oldProperty) != propertyType(property):
+			changes = append(changes, &Change{Kind: FieldTypeChanged, Name: new.name, Field: property.Name,
+				OldType: propertyType(oldProperty), NewType: propertyType(property)})
+		}
+	}
+
+	for _, property := range old.properties {
+		if _, ok := newFields[property.Name]; !ok && property.Name != "" {
+			changes = append(changes, &Change{Kind: FieldRemoved, Name: new.name, Field: property.Name, OldType: propertyType(property)})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,118 @@
+package tlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffTestSchema = `
+//@class Content @description A content
+
+contentText text:string = Content;
+contentPhoto#11111111 id:int32 = Content;
+
+chat id:int53 title:string = Chat;
+
+---functions---
+
+getChat chat_id:int53 = Chat;
+`
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string // replaced in diffTestSchema
+		changes  []string
+	}{
+		{name: "unchanged"},
+		{
+			name: "class added",
+			old:  "chat id", new: "chatList id:int32 = ChatList;\nchat id",
+			changes: []string{"added class chatList: ChatList"},
+		},
+		{
+			name: "class removed",
+			old:  "contentText text:string = Content;", new: "",
+			changes: []string{"removed class contentText: Content"},
+		},
+		{
+			name: "class renamed",
+			old:  "contentText text:string", new: "contentMessageText text:string",
+			changes: []string{"renamed class contentText to contentMessageText"},
+		},
+		{
+			name: "field added and removed",
+			old:  "chat id:int53 title:string", new: "chat id:int53 photo:string",
+			changes: []string{"chat: added field photo:string", "chat: removed field title:string"},
+		},
+		{
+			name: "field type changed",
+			old:  "chat id:int53", new: "chat id:int32",
+			changes: []string{"chat: field id changed type from int53 to int32"},
+		},
+		{
+			name: "interface changed",
+			old:  "contentText text:string = Content;", new: "contentText text:string = Chat;",
+			changes: []string{"contentText: moved from interface Content to Chat"},
+		},
+		{
+			name: "interface removed",
+			old:  "//@class Content @description A content\n", new: "",
+			changes: []string{"removed interface Content"},
+		},
+		{
+			name: "constructor ID changed",
+			old:  "contentPhoto#11111111", new: "contentPhoto#22222222",
+			changes: []string{"contentPhoto: constructor ID changed from #11111111 to #22222222"},
+		},
+		{
+			// only explicit IDs are compared, not the computed ones changing with any field
+			name: "constructor ID added",
+			old:  "contentText text", new: "contentText#33333333 text",
+		},
+		{
+			name: "function added",
+			old:  "getChat chat_id", new: "getChats = Chat;\ngetChat chat_id",
+			changes: []string{"added function getChats: Chat"},
+		},
+		{
+			name: "function removed",
+			old:  "getChat chat_id:int53 = Chat;", new: "",
+			changes: []string{"removed function getChat: Chat"},
+		},
+		{
+			name: "function return type changed",
+			old:  "getChat chat_id:int53 = Chat;", new: "getChat chat_id:int53 = Content;",
+			changes: []string{"getChat: return type changed from Chat to Content"},
+		},
+		{
+			name: "function parameter added",
+			old:  "getChat chat_id:int53", new: "getChat chat_id:int53 force:Bool",
+			changes: []string{"getChat: added field force:Bool"},
+		},
+	}
+
+	old := parseDiffTestSchema(t, diffTestSchema)
+	for _, test := range tests {
+		new := parseDiffTestSchema(t, strings.Replace(diffTestSchema, test.old, test.new, 1))
+
+		var got []string
+		for _, change := range Diff(old, new) {
+			got = append(got, change.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.changes, "\n") {
+			t.Errorf("%s: got changes\n%s\nwant\n%s", test.name, strings.Join(got, "\n"), strings.Join(test.changes, "\n"))
+		}
+	}
+}
+
+func parseDiffTestSchema(t *testing.T, src string) *TlSchema {
+	t.Helper()
+
+	schema, err := ParseInputSchema(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
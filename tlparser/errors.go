@@ -0,0 +1,39 @@
+package tlparser
+
+import "fmt"
+
+// ParseError describes a malformed part of a .tl file
+type ParseError struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`   // 1-based line number
+	Column int    `json:"column"` // 1-based byte offset in the line
+	Text   string `json:"text"`   // the offending text
+	Reason string `json:"reason"`
+}
+
+func (e *ParseError) Error() string {
+	file := e.File
+	if file == "" {
+		file = "<input>"
+	}
+
+	if e.Text == "" {
+		return fmt.Sprintf("%s:%d:%d: %s", file, e.Line, e.Column, e.Reason)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %q", file, e.Line, e.Column, e.Reason, e.Text)
+}
+
+// ParseErrors is the list of errors found while parsing a .tl file, in the order they appear in the file
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "no errors"
+	case 1:
+		return errs[0].Error()
+	}
+
+	return fmt.Sprintf("%s (and %d more errors)", errs[0], len(errs)-1)
+}
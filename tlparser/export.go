@@ -0,0 +1,29 @@
+package tlparser
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SchemaFormatVersion is the version of the JSON document written by WriteJSON.
+// It is increased whenever a field is removed or changes meaning, adding fields does not change it.
+const SchemaFormatVersion = 1
+
+// SchemaDocument is the JSON envelope of an exported schema
+type SchemaDocument struct {
+	FormatVersion int       `json:"format_version"` // SchemaFormatVersion at the time of export
+	Source        string    `json:"source"`         // the file or URL the schema was read from
+	Schema        *TlSchema `json:"schema"`
+}
+
+// WriteJSON writes schema to w as an indented SchemaDocument
+func WriteJSON(w io.Writer, source string, schema *TlSchema) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(&SchemaDocument{
+		FormatVersion: SchemaFormatVersion,
+		Source:        source,
+		Schema:        schema,
+	})
+}
@@ -3,157 +3,608 @@ package tlparser
 import (
 	"bufio"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// ParseInputSchema parses a .tl schema. If the schema is malformed, the returned error is
+// a ParseErrors listing every problem found, along with the part of the schema that could be parsed.
 func ParseInputSchema(reader io.Reader) (*TlSchema, error) {
-	scanner := bufio.NewScanner(reader)
-	var hitFunctions = false
+	return ParseInputSchemaFile("", reader)
+}
+
+// ParseInputSchemaFile is like ParseInputSchema, fileName is only used to report errors.
+func ParseInputSchemaFile(fileName string, reader io.Reader) (*TlSchema, error) {
+	file, err := ParseFile(fileName, reader)
+
+	return file.Schema(), err
+}
+
+// ParseFile parses a .tl file into its syntax tree. If the file is malformed, the returned error
+// is a ParseErrors listing every problem found, along with the declarations that could be parsed.
+func ParseFile(fileName string, reader io.Reader) (*File, error) {
+	p := &parser{
+		scanner:  bufio.NewScanner(reader),
+		fileName: fileName,
+	}
+
+	file := p.parse()
+	if err := p.scanner.Err(); err != nil {
+		return file, err
+	}
+
+	if len(p.errors) > 0 {
+		sort.SliceStable(p.errors, func(i, j int) bool {
+			if p.errors[i].Line != p.errors[j].Line {
+				return p.errors[i].Line < p.errors[j].Line
+			}
+			return p.errors[i].Column < p.errors[j].Column
+		})
+		return file, p.errors
+	}
+
+	return file, nil
+}
+
+// token is a whitespace separated part of a line
+type token struct {
+	text   string
+	column int
+}
+
+type parser struct {
+	scanner  *bufio.Scanner
+	fileName string
+	line     int    // number of the current line
+	text     string // text of the current line
+	reread   bool   // whether next should return the current line again
+	errors   ParseErrors
+
+	file *File
+}
+
+func (p *parser) next() bool {
+	if p.reread {
+		p.reread = false
+		return true
+	}
 
-	schema := &TlSchema{
-		Enums:      []*EnumInfo{},
-		Classes:    []*ClassInfo{},
-		Interfaces: []*InterfaceInfo{},
-		Functions:  []*FunctionInfo{},
+	if !p.scanner.Scan() {
+		return false
 	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	p.line++
+	p.text = p.scanner.Text()
+
+	return true
+}
+
+// backup makes the next call to next return the current line again
+func (p *parser) backup() {
+	p.reread = true
+}
+
+// pos returns the position of column in the current line
+func (p *parser) pos(column int) Position {
+	return Position{File: p.fileName, Line: p.line, Column: column}
+}
+
+// lineSpan returns the span of the current line
+func (p *parser) lineSpan() Span {
+	return Span{From: p.pos(1), To: p.pos(len(p.text) + 1)}
+}
+
+func (p *parser) error(line, column int, text, reason string) {
+	p.errors = append(p.errors, &ParseError{
+		File:   p.fileName,
+		Line:   line,
+		Column: column,
+		Text:   text,
+		Reason: reason,
+	})
+}
+
+func (p *parser) parse() *File {
+	p.file = &File{
+		Span:         Span{From: Position{File: p.fileName, Line: 1, Column: 1}},
+		Name:         p.fileName,
+		Declarations: []*Declaration{},
+		Comments:     []*Comment{},
+	}
+
+	var plainComment *Comment
+
+	for p.next() {
+		line := p.text
+
+		if !strings.HasPrefix(line, "//") || strings.HasPrefix(line, "//@") {
+			plainComment = nil
+		}
 
 		switch {
 		case strings.HasPrefix(line, "//@description"):
-			if hitFunctions {
-				schema.Functions = append(schema.Functions, parseFunction(line, scanner))
-			} else {
-				typeInfo := parseType(line, scanner)
-
-				schema.Classes = append(schema.Classes, typeInfo)
-				// Append to enum Items if this is sub-class of an abstract class.
-				for _, enumInfo := range schema.Enums {
-					if strings.TrimSuffix(enumInfo.EnumType, "Enum") == typeInfo.RootName {
-						enumInfo.Items = append(enumInfo.Items, EnumInfoItem{OriginalType: typeInfo.Name, GolangType: replaceKeyWords(strings.ToUpper(typeInfo.Name[0:1]) + typeInfo.Name[1:])})
+			doc, ok := p.parseDocComment()
+			if !ok {
+				continue
+			}
 
-						break
-					}
-				}
+			if combinator := p.parseCombinator(doc); combinator != nil {
+				p.addDeclaration(&Declaration{
+					Span:       Span{From: doc.From, To: combinator.To},
+					Kind:       CombinatorDeclaration,
+					Doc:        doc,
+					Combinator: combinator,
+				})
 			}
+
 		case strings.HasPrefix(line, "//@class "):
-			interfaceInfo := parseTlAbstractClass(line)
-			schema.Interfaces = append(schema.Interfaces, interfaceInfo)
+			doc := p.newComment()
+			if len(doc.Tags) < 2 || doc.Tags[1].Name != "description" {
+				p.error(p.line, 1, p.text, "expected '//@class Name @description ...'")
+				continue
+			}
 
-			enumInfo := &EnumInfo{EnumType: replaceKeyWords(interfaceInfo.Name) + "Enum"}
-			schema.Enums = append(schema.Enums, enumInfo)
+			p.addDeclaration(&Declaration{
+				Span: doc.Span,
+				Kind: ClassDeclaration,
+				Doc:  doc,
+			})
 
-		case line == "":
+		case strings.TrimSpace(line) == "":
 
 		case strings.Contains(line, "---functions---"):
-			hitFunctions = true
+			p.addDeclaration(&Declaration{Span: p.lineSpan(), Kind: SectionDeclaration, Section: "functions"})
+
+		case strings.Contains(line, "---types---"):
+			p.addDeclaration(&Declaration{Span: p.lineSpan(), Kind: SectionDeclaration, Section: "types"})
+
+		case strings.HasPrefix(line, "//"):
+			if plainComment == nil {
+				plainComment = p.newComment()
+			} else {
+				plainComment.Lines = append(plainComment.Lines, line)
+				plainComment.To = p.lineSpan().To
+			}
 
+		default:
+			// undocumented declaration, as in the built-in types and MTProto schemas
+			if combinator := p.parseCombinator(nil); combinator != nil {
+				p.addDeclaration(&Declaration{
+					Span:       combinator.Span,
+					Kind:       CombinatorDeclaration,
+					Combinator: combinator,
+				})
+			}
 		}
+	}
+
+	p.file.To = p.pos(len(p.text) + 1)
 
+	return p.file
+}
+
+func (p *parser) addDeclaration(decl *Declaration) {
+	p.file.Declarations = append(p.file.Declarations, decl)
+}
+
+// newComment starts a Comment at the current line and adds it to the file
+func (p *parser) newComment() *Comment {
+	comment := &Comment{
+		Span:  p.lineSpan(),
+		Lines: []string{p.text},
+		Tags:  p.parseDocTags(0),
 	}
+	p.file.Comments = append(p.file.Comments, comment)
 
-	return schema, nil
+	return comment
 }
 
-func parseTlAbstractClass(line string) *InterfaceInfo {
-	tlInterface := &InterfaceInfo{
-		Name:        "",
-		Description: "",
+// parseDocComment parses the documentation comment starting at the current line,
+// leaving the declaration following it as the current line.
+func (p *parser) parseDocComment() (*Comment, bool) {
+	doc := p.newComment()
+
+	for {
+		if !p.next() {
+			p.error(doc.From.Line, 1, "", "expected a declaration after the documentation comment, got end of file")
+			return nil, false
+		}
+
+		line := p.text
+		if strings.HasPrefix(line, "//") {
+			doc.Lines = append(doc.Lines, line)
+			doc.To = p.lineSpan().To
+		}
+
+		if strings.HasPrefix(line, "//@") {
+			doc.Tags = append(doc.Tags, p.parseDocTags(0)...)
+		} else if strings.HasPrefix(line, "//-") {
+			continued := p.parseDocTags(len("//-"))
+			if len(doc.Tags) > 0 && len(continued) > 0 && continued[0].Name == "" {
+				last := doc.Tags[len(doc.Tags)-1]
+				last.Value = strings.TrimSpace(last.Value + " " + continued[0].Value)
+				last.To = continued[0].To
+				continued = continued[1:]
+			}
+			doc.Tags = append(doc.Tags, continued...)
+		} else if strings.HasPrefix(line, "//") {
+			// plain comments are not part of the documentation
+		} else if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "---") {
+			p.error(doc.From.Line, 1, "", "expected a declaration after the documentation comment")
+			p.backup()
+			return nil, false
+		} else {
+			return doc, true
+		}
+	}
+}
+
+// parseCombinator parses the declaration at the current line, documented by doc.
+// It returns nil if the declaration is malformed.
+func (p *parser) parseCombinator(doc *Comment) *Combinator {
+	tokens := splitTokens(p.text)
+
+	equals := -1
+	for i, tok := range tokens {
+		if tok.text == "=" {
+			equals = i
+			break
+		}
 	}
 
-	interfaceLineParts := strings.Split(line, "@")
+	if equals < 1 || equals == len(tokens)-1 {
+		p.error(p.line, tokens[0].column, p.text, "expected 'name field:type ... = Type;'")
+		return nil
+	}
 
-	_, tlInterface.Name = parseProperty(interfaceLineParts[1])
-	_, tlInterface.Description = parseProperty(interfaceLineParts[2])
+	last := tokens[len(tokens)-1]
+	if !strings.HasSuffix(last.text, ";") {
+		p.error(p.line, last.column+len(last.text), last.text, "missing ';' at the end of the declaration")
+		return nil
+	}
+
+	combinator := &Combinator{
+		Span:   Span{From: p.pos(tokens[0].column), To: p.pos(last.column + len(last.text))},
+		Fields: []*Field{},
+	}
+	ok := p.parseCombinatorName(tokens[0], combinator)
 
-	return tlInterface
+	args := tokens[1:equals]
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+
+		switch {
+		case arg.text == "?":
+			// 'int ? = Int;' declares a built-in type
+			combinator.Builtin = true
+
+		case strings.HasPrefix(arg.text, "{"):
+			typeParam := p.parseTypeParam(arg)
+			if typeParam == nil {
+				ok = false
+				continue
+			}
+			combinator.TypeParams = append(combinator.TypeParams, typeParam)
+
+		case strings.Contains(arg.text, "["):
+			// repetition such as '[ t ]' or '4*[ int ]', kept as a single anonymous field
+			parts := []token{arg}
+			for !strings.Contains(parts[len(parts)-1].text, "]") && len(args) > 0 {
+				parts = append(parts, args[0])
+				args = args[1:]
+			}
+			if !strings.Contains(parts[len(parts)-1].text, "]") {
+				p.error(p.line, arg.column, arg.text, "missing ']' in repetition")
+				ok = false
+				continue
+			}
+			repetition := p.parseRepetition(parts)
+			if repetition == nil {
+				ok = false
+				continue
+			}
+			combinator.Fields = append(combinator.Fields, &Field{Span: repetition.Span, Type: repetition})
+
+		case arg.text == "#":
+			// anonymous flags field of the built-in vector declaration
+			typeExpr := p.parseTypeExpr(arg.text, arg.column)
+			combinator.Fields = append(combinator.Fields, &Field{Span: typeExpr.Span, Type: typeExpr})
+
+		default:
+			field := p.parseField(arg)
+			if field == nil {
+				ok = false
+				continue
+			}
+			combinator.Fields = append(combinator.Fields, field)
+		}
+	}
+
+	result := append([]token{}, tokens[equals+1:]...)
+	result[len(result)-1].text = strings.TrimRight(last.text, ";")
+	if result[len(result)-1].text == "" {
+		result = result[:len(result)-1]
+	}
+	if len(result) == 0 {
+		p.error(p.line, last.column, last.text, "missing result type")
+		return nil
+	}
+
+	combinator.Result = p.parseTypeExpr(result[0].text, result[0].column)
+	for _, arg := range result[1:] {
+		typeExpr := p.parseTypeExpr(arg.text, arg.column)
+		combinator.Result.Args = append(combinator.Result.Args, typeExpr)
+		combinator.Result.Text += " " + typeExpr.Text
+		combinator.Result.To = typeExpr.To
+	}
+
+	for _, tag := range docTags(doc) {
+		if tag.Name == "" || tag.Name == "description" {
+			continue
+		}
+
+		if getField(combinator.Fields, strings.TrimPrefix(tag.Name, "param_")) == nil {
+			p.error(tag.From.Line, tag.From.Column, "@"+tag.Name, "documentation of unknown field")
+			ok = false
+		}
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return combinator
 }
 
-func parseType(firstLine string, scanner *bufio.Scanner) *ClassInfo {
-	name, description, class, properties, _ := parseEntity(firstLine, scanner)
-	return &ClassInfo{
-		Name:        name,
-		Description: description,
-		RootName:    class,
-		Properties:  properties,
+// parseCombinatorName parses 'name' or 'name#crc32' into combinator
+func (p *parser) parseCombinatorName(tok token, combinator *Combinator) bool {
+	parts := strings.SplitN(tok.text, "#", 2)
+	combinator.Name = parts[0]
+
+	if combinator.Name == "" {
+		p.error(p.line, tok.column, tok.text, "missing combinator name")
+		return false
+	}
+
+	if len(parts) == 2 {
+		id, err := strconv.ParseUint(parts[1], 16, 32)
+		if err != nil {
+			p.error(p.line, tok.column+len(parts[0])+1, parts[1], "invalid constructor ID, expected up to 8 hexadecimal digits")
+			return false
+		}
+		combinator.ID = uint32(id)
 	}
+
+	return true
 }
 
-func parseFunction(firstLine string, scanner *bufio.Scanner) *FunctionInfo {
-	name, description, class, properties, isSynchronous := parseEntity(firstLine, scanner)
-	return &FunctionInfo{
-		Name:          name,
-		Description:   description,
-		ReturnType:    class,
-		Properties:    properties,
-		IsSynchronous: isSynchronous,
+// parseField parses 'name:type' or 'name:flags.N?type'
+func (p *parser) parseField(tok token) *Field {
+	propertyParts := strings.SplitN(tok.text, ":", 2)
+	if len(propertyParts) != 2 || propertyParts[0] == "" || propertyParts[1] == "" {
+		p.error(p.line, tok.column, tok.text, "expected field in the form 'name:type'")
+		return nil
+	}
+
+	field := &Field{
+		Span: Span{From: p.pos(tok.column), To: p.pos(tok.column + len(tok.text))},
+		Name: propertyParts[0],
 	}
+
+	typeText := propertyParts[1]
+	typeColumn := tok.column + len(field.Name) + 1
+
+	question := strings.Index(typeText, "?")
+	if question >= 0 {
+		condition := typeText[:question]
+
+		dot := strings.LastIndex(condition, ".")
+		if dot <= 0 {
+			p.error(p.line, typeColumn, condition, "expected condition in the form 'flags.N'")
+			return nil
+		}
+
+		bit, err := strconv.Atoi(condition[dot+1:])
+		if err != nil || bit < 0 || bit > 31 {
+			p.error(p.line, typeColumn+dot+1, condition[dot+1:], "invalid flag bit, expected a number between 0 and 31")
+			return nil
+		}
+
+		if question == len(typeText)-1 {
+			p.error(p.line, tok.column, tok.text, "missing type after the condition")
+			return nil
+		}
+
+		field.FlagField = condition[:dot]
+		field.FlagBit = bit
+		typeText = typeText[question+1:]
+		typeColumn += question + 1
+	}
+
+	field.Type = p.parseTypeExpr(typeText, typeColumn)
+
+	return field
 }
 
-func parseEntity(firstLine string, scanner *bufio.Scanner) (string, string, string, []Property, bool) {
-	name := ""
-	description := ""
-	rootClass := ""
-	properties := []Property{}
+// parseTypeParam parses '{name:type}'
+func (p *parser) parseTypeParam(tok token) *Field {
+	parts := strings.SplitN(strings.TrimSuffix(tok.text[1:], "}"), ":", 2)
+	if !strings.HasSuffix(tok.text, "}") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		p.error(p.line, tok.column, tok.text, "expected type parameter in the form '{name:type}'")
+		return nil
+	}
 
-	propertiesLine := strings.TrimPrefix(firstLine, "//")
+	return &Field{
+		Span: Span{From: p.pos(tok.column), To: p.pos(tok.column + len(tok.text))},
+		Name: parts[0],
+		Type: p.parseTypeExpr(parts[1], tok.column+1+len(parts[0])+1),
+	}
+}
 
-Loop:
-	for scanner.Scan() {
-		line := scanner.Text()
+// parseRepetition parses the tokens of '[ t ]' or 'N*[ t ]'.
+// It returns nil if the closing bracket does not follow the opening one.
+func (p *parser) parseRepetition(tokens []token) *TypeExpr {
+	first, last := tokens[0], tokens[len(tokens)-1]
+	texts := []string{}
+	for _, tok := range tokens {
+		texts = append(texts, tok.text)
+	}
 
-		switch {
-		case strings.HasPrefix(line, "//@"):
-			propertiesLine += " " + strings.TrimPrefix(line, "//")
+	repetition := &TypeExpr{
+		Span:       Span{From: p.pos(first.column), To: p.pos(last.column + len(last.text))},
+		Text:       strings.Join(texts, " "),
+		Repetition: true,
+	}
 
-		case strings.HasPrefix(line, "//-"):
-			propertiesLine += " " + strings.TrimPrefix(line, "//-")
+	// the repeated types are the tokens between the brackets
+	for i, tok := range tokens {
+		text, column := tok.text, tok.column
+		if i == 0 {
+			open := strings.Index(text, "[")
+			repetition.Multiplicity = strings.TrimSuffix(text[:open], "*")
+			text, column = text[open+1:], column+open+1
+		}
+		if i == len(tokens)-1 {
+			end := strings.Index(text, "]")
+			if end < 0 {
+				p.error(p.line, first.column, repetition.Text, "expected repetition in the form '[ t ]' or 'N*[ t ]'")
+				return nil
+			}
+			text = text[:end]
+		}
+		if text != "" {
+			repetition.Args = append(repetition.Args, p.parseTypeExpr(text, column))
+		}
+	}
 
-		default:
-			bodyFields := strings.Fields(line)
-			name = bodyFields[0]
+	return repetition
+}
 
-			for _, rawProperty := range bodyFields[1 : len(bodyFields)-2] {
-				propertyParts := strings.Split(rawProperty, ":")
-				property := Property{
-					Name: propertyParts[0],
-					Type: propertyParts[1],
-				}
-				properties = append(properties, property)
+// parseTypeExpr parses a type written without spaces, such as 'vector<%Message>', starting at column
+func (p *parser) parseTypeExpr(text string, column int) *TypeExpr {
+	typeExpr := &TypeExpr{
+		Span: Span{From: p.pos(column), To: p.pos(column + len(text))},
+		Text: text,
+	}
+
+	name := text
+	switch {
+	case strings.HasPrefix(name, "%"):
+		typeExpr.Bare = true
+		name = name[1:]
+	case strings.HasPrefix(name, "!"):
+		typeExpr.Bang = true
+		name = name[1:]
+	}
+
+	open := strings.Index(name, "<")
+	if open < 0 || !strings.HasSuffix(name, ">") {
+		typeExpr.Name = name
+		return typeExpr
+	}
+
+	typeExpr.Name = name[:open]
+
+	// split the arguments between the angle brackets around the top level commas
+	argsColumn := column + len(text) - len(name) + open + 1
+	args := name[open+1 : len(name)-1]
+	depth, start := 0, 0
+	for i := 0; i <= len(args); i++ {
+		if i < len(args) {
+			switch args[i] {
+			case '<':
+				depth++
+			case '>':
+				depth--
+			}
+			if args[i] != ',' || depth > 0 {
+				continue
 			}
-			rootClass = strings.TrimRight(bodyFields[len(bodyFields)-1], ";")
-			break Loop
 		}
+		typeExpr.Args = append(typeExpr.Args, p.parseTypeExpr(args[start:i], argsColumn+start))
+		start = i + 1
 	}
 
-	rawProperties := strings.Split(propertiesLine, "@")
-	for _, rawProperty := range rawProperties[1:] {
-		name, value := parseProperty(rawProperty)
-		switch {
-		case name == "description":
-			description = value
-		default:
-			name = strings.TrimPrefix(name, "param_")
-			property := getProperty(properties, name)
-			property.Description = value
+	return typeExpr
+}
 
+// parseDocTags splits the current comment line, starting at offset, into its '@name value' tags.
+// Any text before the first '@' is returned as a tag with an empty name.
+func (p *parser) parseDocTags(offset int) []*DocTag {
+	tags := []*DocTag{}
+	line := p.text
+
+	text := strings.TrimPrefix(line[offset:], "//")
+	offset = len(line) - len(text)
+
+	for i, rawTag := range strings.Split(text, "@") {
+		// the tag starts at its '@', which is right before rawTag
+		span := Span{From: p.pos(offset), To: p.pos(offset + len(rawTag) + 1)}
+		if i == 0 {
+			span.From.Column++
+		}
+		offset += len(rawTag) + 1
+
+		if i == 0 {
+			if value := strings.TrimSpace(rawTag); value != "" {
+				tags = append(tags, &DocTag{Span: span, Value: strings.Join(strings.Fields(value), " ")})
+			}
+			continue
 		}
+
+		name, value := parseProperty(rawTag)
+		if name == "" {
+			p.error(p.line, span.From.Column, "@"+rawTag, "expected a tag name after '@'")
+			continue
+		}
+		tags = append(tags, &DocTag{Span: span, Name: name, Value: value})
 	}
 
-	return name, description, rootClass, properties, strings.Contains(description, "Can be called synchronously")
+	return tags
+}
+
+// splitTokens splits line around whitespace, keeping the column of each part
+func splitTokens(line string) []token {
+	tokens := []token{}
+	start := -1
+
+	for i, r := range line + " " {
+		if r == ' ' || r == '\t' {
+			if start >= 0 {
+				tokens = append(tokens, token{text: line[start:i], column: start + 1})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+
+	return tokens
+}
+
+func docTags(doc *Comment) []*DocTag {
+	if doc == nil {
+		return nil
+	}
+
+	return doc.Tags
 }
 
 func parseProperty(str string) (string, string) {
 	strParts := strings.Fields(str)
+	if len(strParts) == 0 {
+		return "", ""
+	}
 
 	return strParts[0], strings.Join(strParts[1:], " ")
 }
 
-func getProperty(properties []Property, name string) *Property {
-	for i, property := range properties {
-		if property.Name == name {
-			return &properties[i]
+func getField(fields []*Field, name string) *Field {
+	for _, field := range fields {
+		if field.Name == name {
+			return field
 		}
 	}
 
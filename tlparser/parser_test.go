@@ -0,0 +1,74 @@
+package tlparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		line   int
+		column int
+		reason string
+	}{
+		{"foo ]int[ = X;", 1, 5, "expected repetition in the form '[ t ]' or 'N*[ t ]'"},
+		{"foo ][ = X;", 1, 5, "expected repetition in the form '[ t ]' or 'N*[ t ]'"},
+		{"foo [ int = X;", 1, 5, "missing ']' in repetition"},
+		{"foo a:int = X", 1, 14, "missing ';' at the end of the declaration"},
+		{"foo a:int;", 1, 1, "expected 'name field:type ... = Type;'"},
+		{"foo#xyz a:int = X;", 1, 5, "invalid constructor ID, expected up to 8 hexadecimal digits"},
+		{"foo a:flags.32?int = X;", 1, 13, "invalid flag bit, expected a number between 0 and 31"},
+		{"//@description x @zz no such\nfoo a:int = X;", 1, 18, "documentation of unknown field"},
+		{"//@description x\n\nfoo = X;", 1, 1, "expected a declaration after the documentation comment"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFile("", strings.NewReader(test.src))
+
+		var parseErrors ParseErrors
+		if !errors.As(err, &parseErrors) || len(parseErrors) == 0 {
+			t.Errorf("%q: got %v, want ParseErrors", test.src, err)
+			continue
+		}
+		got := parseErrors[0]
+		if got.Line != test.line || got.Column != test.column || got.Reason != test.reason {
+			t.Errorf("%q: got %d:%d %q, want %d:%d %q", test.src, got.Line, got.Column, got.Reason, test.line, test.column, test.reason)
+		}
+	}
+}
+
+func TestParseRepetition(t *testing.T) {
+	file, err := ParseFile("", strings.NewReader("foo n:# 4*[ a:int b:long ] = X;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repetition := file.Declarations[0].Combinator.Fields[1].Type
+	if !repetition.Repetition || repetition.Multiplicity != "4" || len(repetition.Args) != 2 {
+		t.Errorf("got %+v", repetition)
+	}
+}
+
+func TestParseDescriptionField(t *testing.T) {
+	schema, err := ParseInputSchema(strings.NewReader("//@description A foo @param_description Its description\n" +
+		"foo description:string = Foo;\n//@description A bar\nbar description:string = Bar;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foo, bar := schema.Classes[0], schema.Classes[1]
+	if foo.Description != "A foo" || foo.Properties[0].Description != "Its description" {
+		t.Errorf("foo: got %q, field %q", foo.Description, foo.Properties[0].Description)
+	}
+	// the description of bar is not that of its field, which is not documented
+	if bar.Properties[0].Description != "" {
+		t.Errorf("bar: got field %q", bar.Properties[0].Description)
+	}
+
+	diagnostics := Validate(schema)
+	if len(diagnostics) != 1 || diagnostics[0].Message != "field description of bar is not documented" {
+		t.Errorf("got %v, want the field of bar not documented", diagnostics)
+	}
+}
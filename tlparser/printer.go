@@ -0,0 +1,175 @@
+package tlparser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// docLineWidth is the width documentation comments are wrapped at, continuation lines start with '//-'
+const docLineWidth = 120
+
+// Print writes schema to w as a canonical .tl file, see Format
+func Print(w io.Writer, schema *TlSchema) error {
+	_, err := w.Write(Format(schema))
+	return err
+}
+
+// Format returns schema as a canonical .tl file: the built-in types, then the classes, each abstract
+// class declared right before its first sub-class, then the functions. Every documentation tag starts
+// a new line, parameters are documented in the order of the fields and long lines are wrapped.
+// Parsing the result gives back the same schema.
+func Format(schema *TlSchema) []byte {
+	p := &printer{}
+
+	for _, builtin := range schema.Builtins {
+		p.combinator(builtin.Decl, builtin.Name, builtin.ID, builtin.TypeParams, builtin.Properties, builtin.RootName, isDeclaredBuiltin(builtin))
+	}
+
+	printed := 0 // number of schema.Interfaces already printed
+	for _, class := range schema.Classes {
+		for i := printed; i < len(schema.Interfaces); i++ {
+			if schema.Interfaces[i].Name == class.RootName {
+				for _, interfaceInfo := range schema.Interfaces[printed : i+1] {
+					p.class(interfaceInfo)
+				}
+				printed = i + 1
+				break
+			}
+		}
+
+		p.doc(class.Description, class.Properties)
+		p.combinator(class.Decl, class.Name, class.ID, class.TypeParams, class.Properties, class.RootName, false)
+	}
+
+	for _, interfaceInfo := range schema.Interfaces[printed:] {
+		p.class(interfaceInfo)
+	}
+
+	if len(schema.Functions) > 0 {
+		p.separate(true)
+		p.buf.WriteString("---functions---\n")
+		p.lastDocumented = true
+
+		for _, function := range schema.Functions {
+			p.doc(function.Description, function.Properties)
+			p.combinator(function.Decl, function.Name, function.ID, function.TypeParams, function.Properties, function.ReturnType, false)
+		}
+	}
+
+	return p.buf.Bytes()
+}
+
+type printer struct {
+	buf            bytes.Buffer
+	documented     bool // whether the declaration being printed is documented
+	lastDocumented bool // whether the previous declaration was documented
+}
+
+// separate writes the blank line between declarations, documented ones are separated from all others
+func (p *printer) separate(documented bool) {
+	if p.buf.Len() > 0 && (documented || p.lastDocumented) {
+		p.buf.WriteString("\n")
+	}
+}
+
+func (p *printer) class(interfaceInfo *InterfaceInfo) {
+	p.separate(true)
+	p.buf.WriteString(fmt.Sprintf("//@class %s @description %s\n", interfaceInfo.Name, interfaceInfo.Description))
+	p.lastDocumented = true
+}
+
+// doc writes the documentation comment of a class or a function, if it has one
+func (p *printer) doc(description string, properties []Property) {
+	tags := []string{}
+	if description != "" {
+		tags = append(tags, "@description "+description)
+	}
+
+	for _, property := range properties {
+		if property.Name == "" || property.Description == "" {
+			continue
+		}
+
+		name := property.Name
+		if name == "description" {
+			// '@description' documents the combinator itself
+			name = "param_" + name
+		}
+		tags = append(tags, "@"+name+" "+property.Description)
+	}
+
+	p.documented = len(tags) > 0
+	if !p.documented {
+		return
+	}
+	if !strings.HasPrefix(tags[0], "@description") {
+		// the parser only looks for documentation starting with '@description'
+		tags = append([]string{"@description"}, tags...)
+	}
+
+	p.separate(true)
+	for _, tag := range tags {
+		p.wrap("//"+tag, "//-")
+	}
+}
+
+// wrap writes text, breaking it into lines of docLineWidth at most, continuation lines start with prefix
+func (p *printer) wrap(text, prefix string) {
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) > docLineWidth:
+			p.buf.WriteString(line + "\n")
+			line = prefix + word
+		default:
+			line += " " + word
+		}
+	}
+	p.buf.WriteString(line + "\n")
+}
+
+func (p *printer) combinator(decl *Declaration, name string, id uint32, typeParams []TypeParam, properties []Property, resultType string, builtin bool) {
+	if !p.documented {
+		p.separate(false)
+	}
+
+	parts := []string{name}
+	if id != 0 {
+		parts[0] += fmt.Sprintf("#%x", id)
+	}
+
+	for _, typeParam := range typeParams {
+		parts = append(parts, "{"+typeParam.Name+":"+typeParam.Type+"}")
+	}
+
+	for _, property := range properties {
+		switch {
+		case property.Name == "":
+			parts = append(parts, property.Type)
+		case property.IsConditional():
+			parts = append(parts, property.Name+":"+property.Condition+"?"+property.Type)
+		default:
+			parts = append(parts, property.Name+":"+property.Type)
+		}
+	}
+
+	if builtin || (decl != nil && decl.Combinator != nil && decl.Combinator.Builtin) {
+		parts = append(parts, "?")
+	}
+
+	p.buf.WriteString(strings.Join(parts, " ") + " = " + resultType + ";\n")
+
+	p.lastDocumented = p.documented
+	p.documented = false
+}
+
+// isDeclaredBuiltin reports whether a built-in class needs the '?' marker to be parsed as one again
+func isDeclaredBuiltin(builtin *ClassInfo) bool {
+	fields := strings.Fields(builtin.RootName)
+
+	return len(fields) == 0 || !builtinTypes[fields[0]]
+}
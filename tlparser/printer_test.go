@@ -0,0 +1,65 @@
+package tlparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseTestFile parses a schema of testdata
+func parseTestFile(t *testing.T, name string) *TlSchema {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	schema, err := ParseInputSchemaFile(name, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return schema
+}
+
+// schemaJSON returns what schema declares, without positions nor diagnostics
+func schemaJSON(t *testing.T, schema *TlSchema) []byte {
+	t.Helper()
+
+	withoutDiagnostics := *schema
+	withoutDiagnostics.Diagnostics = nil
+	data, err := json.Marshal(withoutDiagnostics)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, name := range []string{"td_api.tl", "api.tl"} {
+		t.Run(name, func(t *testing.T) {
+			schema := parseTestFile(t, name)
+			if len(schema.Classes) == 0 || len(schema.Functions) == 0 {
+				t.Fatalf("parsed %d classes and %d functions", len(schema.Classes), len(schema.Functions))
+			}
+
+			formatted := Format(schema)
+			reparsed, err := ParseInputSchemaFile(name, bytes.NewReader(formatted))
+			if err != nil {
+				t.Fatalf("formatted schema does not parse: %s\n%s", err, formatted)
+			}
+			if want, got := schemaJSON(t, schema), schemaJSON(t, reparsed); !bytes.Equal(want, got) {
+				t.Errorf("formatting changed the schema\nbefore: %s\nafter:  %s", want, got)
+			}
+
+			if again := Format(reparsed); !bytes.Equal(formatted, again) {
+				t.Errorf("formatting is not idempotent\nfirst:\n%s\nsecond:\n%s", formatted, again)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package tlparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Arman92/go-tl-parser/naming"
+)
+
+// builtinTypes are the types whose declarations go to TlSchema.Builtins
+var builtinTypes = map[string]bool{
+	"Bool": true, "True": true, "Vector": true, "Int": true, "Long": true, "Double": true,
+	"String": true, "Bytes": true, "Int32": true, "Int53": true, "Int64": true, "Int128": true, "Int256": true,
+}
+
+// Schema derives the TlSchema from the syntax tree of a .tl file
+func (file *File) Schema() *TlSchema {
+	var hitFunctions = false
+
+	schema := &TlSchema{
+		Enums:      []*EnumInfo{},
+		Classes:    []*ClassInfo{},
+		Interfaces: []*InterfaceInfo{},
+		Functions:  []*FunctionInfo{},
+		Builtins:   []*ClassInfo{},
+
+		Diagnostics: []*Diagnostic{},
+	}
+
+	for _, decl := range file.Declarations {
+		switch decl.Kind {
+		case SectionDeclaration:
+			hitFunctions = decl.Section == "functions"
+
+		case ClassDeclaration:
+			interfaceInfo := &InterfaceInfo{
+				Name:        decl.Doc.Tags[0].Value,
+				Description: decl.Doc.Tags[1].Value,
+				Decl:        decl,
+			}
+			schema.Interfaces = append(schema.Interfaces, interfaceInfo)
+
+			enumInfo := &EnumInfo{EnumType: naming.Default.Exported(interfaceInfo.Name) + "Enum"}
+			schema.Enums = append(schema.Enums, enumInfo)
+
+		case CombinatorDeclaration:
+			schema.addCombinator(decl, hitFunctions)
+		}
+	}
+
+	return schema
+}
+
+func (schema *TlSchema) addCombinator(decl *Declaration, isFunction bool) {
+	combinator := decl.Combinator
+
+	description, _ := decl.Doc.Tag("description")
+	typeParams := []TypeParam{}
+	for _, typeParam := range combinator.TypeParams {
+		typeParams = append(typeParams, TypeParam{Name: typeParam.Name, Type: typeParam.Type.Text})
+	}
+	if len(typeParams) == 0 {
+		typeParams = nil
+	}
+
+	properties := []Property{}
+	for _, field := range combinator.Fields {
+		property := Property{
+			Name:      field.Name,
+			Type:      field.Type.Text,
+			Condition: field.Condition(),
+			FlagField: field.FlagField,
+			FlagBit:   field.FlagBit,
+		}
+		for _, tag := range docTags(decl.Doc) {
+			// '@description' is that of the combinator, a field named description is documented with '@param_description'
+			if tag.Name != "" && tag.Name != "description" && strings.TrimPrefix(tag.Name, "param_") == field.Name {
+				property.Description = tag.Value
+			}
+		}
+		properties = append(properties, property)
+	}
+
+	crc := computeConstructorID(combinatorText(combinator.Name, typeParams, properties, combinator.Result.Text))
+	if combinator.ID != 0 && combinator.ID != crc {
+		schema.Diagnostics = append(schema.Diagnostics, &Diagnostic{
+			File:     combinator.From.File,
+			Line:     combinator.From.Line,
+			Column:   combinator.From.Column,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("constructor ID of %s is #%08x, but its computed CRC32 is #%08x", combinator.Name, combinator.ID, crc),
+		})
+	}
+
+	if isFunction {
+		schema.Functions = append(schema.Functions, &FunctionInfo{
+			Name:          combinator.Name,
+			ID:            combinator.ID,
+			CRC32:         crc,
+			TypeParams:    typeParams,
+			Description:   description,
+			ReturnType:    combinator.Result.Text,
+			Properties:    properties,
+			IsSynchronous: strings.Contains(description, "Can be called synchronously"),
+			Decl:          decl,
+		})
+		return
+	}
+
+	typeInfo := &ClassInfo{
+		Name:        combinator.Name,
+		ID:          combinator.ID,
+		CRC32:       crc,
+		TypeParams:  typeParams,
+		Description: description,
+		RootName:    combinator.Result.Text,
+		Properties:  properties,
+		Decl:        decl,
+	}
+
+	if combinator.Builtin || builtinTypes[combinator.Result.Name] {
+		schema.Builtins = append(schema.Builtins, typeInfo)
+		return
+	}
+
+	schema.Classes = append(schema.Classes, typeInfo)
+	// Append to enum Items if this is sub-class of an abstract class, schema.Enums[i] is the enum of schema.Interfaces[i].
+	for i, interfaceInfo := range schema.Interfaces {
+		if interfaceInfo.Name == typeInfo.RootName {
+			enumInfo := schema.Enums[i]
+			enumInfo.Items = append(enumInfo.Items, EnumInfoItem{OriginalType: typeInfo.Name, GolangType: naming.Default.Exported(typeInfo.Name)})
+
+			break
+		}
+	}
+}
@@ -1,49 +1,90 @@
 package tlparser
 
+// TlSchema holds everything declared in a .tl file
 type TlSchema struct {
-	Enums      []*EnumInfo
-	Interfaces []*InterfaceInfo
-	Classes    []*ClassInfo
-	Functions  []*FunctionInfo
+	Enums      []*EnumInfo      `json:"enums"`
+	Interfaces []*InterfaceInfo `json:"interfaces"`
+	Classes    []*ClassInfo     `json:"classes"`
+	Functions  []*FunctionInfo  `json:"functions"`
+	Builtins   []*ClassInfo     `json:"builtins"` // declarations of the built-in types, such as 'int ? = Int;' or 'boolTrue = Bool;'
+
+	Diagnostics []*Diagnostic `json:"diagnostics"` // problems found while parsing, such as constructor ID mismatches
 }
 
 // ClassInfo holds info of a Class in .tl file
 type ClassInfo struct {
-	Name        string     `json:"name"`
-	Properties  []Property `json:"properties"`
-	Description string     `json:"description"`
-	RootName    string     `json:"rootName"`
+	Name        string      `json:"name"`
+	ID          uint32      `json:"id,omitempty"` // the explicit constructor ID in 'name#id', 0 if none
+	CRC32       uint32      `json:"crc32"`        // the constructor ID computed from the declaration
+	TypeParams  []TypeParam `json:"type_params,omitempty"`
+	Properties  []Property  `json:"properties"`
+	Description string      `json:"description"`
+	RootName    string      `json:"rootName"`
+
+	Decl *Declaration `json:"-"` // the declaration in the syntax tree
 }
 
 // FunctionInfo holds info of a function in .tl file
 type FunctionInfo struct {
-	Name          string     `json:"name"`
-	Properties    []Property `json:"properties"`
-	Description   string     `json:"description"`
-	ReturnType    string     `json:"return_type"`
-	IsSynchronous bool       `json:"is_synchronous"`
+	Name          string      `json:"name"`
+	ID            uint32      `json:"id,omitempty"` // the explicit constructor ID in 'name#id', 0 if none
+	CRC32         uint32      `json:"crc32"`        // the constructor ID computed from the declaration
+	TypeParams    []TypeParam `json:"type_params,omitempty"`
+	Properties    []Property  `json:"properties"`
+	Description   string      `json:"description"`
+	ReturnType    string      `json:"return_type"`
+	IsSynchronous bool        `json:"is_synchronous"`
+
+	Decl *Declaration `json:"-"` // the declaration in the syntax tree
+}
+
+// TypeParam is a generic type parameter such as {X:Type}
+type TypeParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 // Property holds info about properties of a class (or function)
 type Property struct {
-	Name        string `json:"name"`
-	Type        string `json:"type"`
+	Name        string `json:"name"` // empty for anonymous fields, such as '#' or '[ t ]' in built-in declarations
+	Type        string `json:"type"` // '#' for flag fields, the type without the condition for conditional ones
 	Description string `json:"description"`
+
+	// Set for conditional fields such as 'reply_to:flags.3?int'
+	Condition string `json:"condition,omitempty"`  // e.g. 'flags.3'
+	FlagField string `json:"flag_field,omitempty"` // e.g. 'flags'
+	FlagBit   int    `json:"flag_bit,omitempty"`   // e.g. 3
+}
+
+// IsFlags reports whether the property is a bit mask for conditional fields ('flags:#')
+func (property *Property) IsFlags() bool {
+	return property.Type == "#"
+}
+
+// IsConditional reports whether the property is only present when a flag bit is set
+func (property *Property) IsConditional() bool {
+	return property.Condition != ""
 }
 
 // InterfaceInfo equals to abstract base classes in .tl file
 type InterfaceInfo struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	Decl *Declaration `json:"-"` // the '//@class' declaration in the syntax tree
 }
 
+// EnumInfoItem is a class of an EnumInfo
 type EnumInfoItem struct {
 	OriginalType string `json:"original_type"`
-	GolangType   string `json:"golang_type"`
+	// GolangType is the Go name of the class with naming.Default, the generator names it with its own Namer
+	GolangType string `json:"golang_type"`
 }
 
-// EnumInfo ...
+// EnumInfo lists the classes of an interface, schema.Enums[i] is the enum of schema.Interfaces[i]
 type EnumInfo struct {
+	// EnumType is the Go name of the interface with naming.Default followed by Enum,
+	// the generator names it with its own Namer
 	EnumType string         `json:"enumType"`
 	Items    []EnumInfoItem `json:"items"`
 }
@@ -0,0 +1,180 @@
+package tlparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// primitiveTypes are the types that can be used without being declared
+var primitiveTypes = map[string]bool{
+	"#": true, "int": true, "long": true, "double": true, "string": true, "bytes": true, "true": true,
+	"int32": true, "int53": true, "int64": true, "int128": true, "int256": true,
+	"Bool": true, "True": true, "vector": true, "Vector": true, "Type": true,
+}
+
+// Validate checks that schema is consistent, and returns the problems it finds sorted by position:
+//   - errors for types that are not declared, duplicate constructor or field names, documentation of fields
+//     that do not exist, and classes whose type is neither an interface declared with '//@class' nor their own name;
+//   - warnings for fields missing documentation in documented declarations.
+func Validate(schema *TlSchema) []*Diagnostic {
+	v := &validator{known: map[string]bool{}}
+
+	for _, interfaceInfo := range schema.Interfaces {
+		v.known[interfaceInfo.Name] = true
+	}
+	for _, classes := range [][]*ClassInfo{schema.Builtins, schema.Classes} {
+		for _, class := range classes {
+			v.known[class.Name] = true
+			v.known[typeName(class.RootName)] = true
+		}
+	}
+
+	interfaces := map[string]bool{}
+	for _, interfaceInfo := range schema.Interfaces {
+		interfaces[interfaceInfo.Name] = true
+	}
+
+	constructors := map[string]*ClassInfo{}
+	for _, classes := range [][]*ClassInfo{schema.Builtins, schema.Classes} {
+		for _, class := range classes {
+			if previous, ok := constructors[class.Name]; ok {
+				v.report(class.Decl, SeverityError, "duplicate constructor %s, also declared at %s", class.Name, declPos(previous.Decl))
+			}
+			constructors[class.Name] = class
+			v.combinator(class.Decl, class.Name, class.TypeParams, class.Properties)
+		}
+	}
+
+	for _, class := range schema.Classes {
+		if !interfaces[class.RootName] && class.RootName != firstUpper(class.Name) {
+			v.report(class.Decl, SeverityError, "type %s of %s is neither a //@class nor %s", class.RootName, class.Name, firstUpper(class.Name))
+		}
+	}
+
+	functions := map[string]*FunctionInfo{}
+	for _, function := range schema.Functions {
+		if previous, ok := functions[function.Name]; ok {
+			v.report(function.Decl, SeverityError, "duplicate function %s, also declared at %s", function.Name, declPos(previous.Decl))
+		}
+		functions[function.Name] = function
+		v.combinator(function.Decl, function.Name, function.TypeParams, function.Properties)
+		v.checkType(function.Decl, function.TypeParams, function.ReturnType, "return type of "+function.Name)
+	}
+
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i], v.diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return v.diagnostics
+}
+
+type validator struct {
+	known       map[string]bool // names of the declared types and constructors
+	diagnostics []*Diagnostic
+}
+
+// combinator checks the fields of a class or function and their documentation,
+// documenting a field the combinator does not have being a parse error
+func (v *validator) combinator(decl *Declaration, name string, typeParams []TypeParam, properties []Property) {
+	fields := map[string]bool{}
+	for _, property := range properties {
+		if property.Name == "" {
+			continue
+		}
+
+		if fields[property.Name] {
+			v.report(decl, SeverityError, "duplicate field %s in %s", property.Name, name)
+		}
+		fields[property.Name] = true
+
+		v.checkType(decl, typeParams, property.Type, "field "+property.Name+" of "+name)
+
+		if decl != nil && decl.Doc != nil && property.Description == "" && !property.IsFlags() {
+			v.report(decl, SeverityWarning, "field %s of %s is not documented", property.Name, name)
+		}
+	}
+}
+
+// checkType reports the names used in tlType which are neither declared nor type parameters
+func (v *validator) checkType(decl *Declaration, typeParams []TypeParam, tlType, what string) {
+	for _, name := range typeNames(tlType) {
+		if v.known[name] || primitiveTypes[name] || isTypeParam(name, typeParams) {
+			continue
+		}
+
+		v.report(decl, SeverityError, "undefined type %s in %s", name, what)
+	}
+}
+
+func (v *validator) report(decl *Declaration, severity Severity, format string, args ...interface{}) {
+	diagnostic := &Diagnostic{Severity: severity, Message: fmt.Sprintf(format, args...)}
+	if decl != nil {
+		diagnostic.File, diagnostic.Line, diagnostic.Column = decl.From.File, decl.From.Line, decl.From.Column
+		if decl.Combinator != nil {
+			// the documentation comment comes first, point at the declaration itself
+			diagnostic.Line, diagnostic.Column = decl.Combinator.From.Line, decl.Combinator.From.Column
+		}
+	}
+
+	v.diagnostics = append(v.diagnostics, diagnostic)
+}
+
+func declPos(decl *Declaration) string {
+	if decl == nil || decl.Combinator == nil {
+		return "an unknown position"
+	}
+
+	return decl.Combinator.Pos().String()
+}
+
+// typeNames returns the names of the types used in a type such as 'vector<%Message>', 'Vector t' or '4*[ int ]'
+func typeNames(tlType string) []string {
+	var names []string
+	for _, word := range strings.FieldsFunc(tlType, func(r rune) bool {
+		return strings.ContainsRune(" <>[]*%!", r)
+	}) {
+		if word != "" && !unicode.IsDigit(rune(word[0])) {
+			names = append(names, word)
+		}
+	}
+
+	return names
+}
+
+// typeName returns the name of a type without its arguments, e.g. 'Vector' for 'Vector t'
+func typeName(tlType string) string {
+	if fields := strings.Fields(tlType); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return tlType
+}
+
+func isTypeParam(name string, typeParams []TypeParam) bool {
+	for _, typeParam := range typeParams {
+		if typeParam.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func firstUpper(str string) string {
+	r, size := utf8.DecodeRuneInString(str)
+	if size == 0 {
+		return str
+	}
+
+	return string(unicode.ToUpper(r)) + str[size:]
+}
@@ -0,0 +1,31 @@
+package tlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTypeOfClass(t *testing.T) {
+	tests := map[string]string{
+		"foo a:int32 = Foo;":       "",
+		"éclair a:int32 = Éclair;": "",
+		"foo a:int32 = Bar;":       "type Bar of foo is neither a //@class nor Foo",
+		"éclair a:int32 = Eclair;": "type Eclair of éclair is neither a //@class nor Éclair",
+	}
+	for src, want := range tests {
+		schema, err := ParseInputSchema(strings.NewReader(src + "\n"))
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+
+		var got []string
+		for _, diagnostic := range Validate(schema) {
+			if diagnostic.Severity == SeverityError {
+				got = append(got, diagnostic.Message)
+			}
+		}
+		if strings.Join(got, "\n") != want {
+			t.Errorf("%s: got %q, want %q", src, got, want)
+		}
+	}
+}
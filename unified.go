@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes in unified diffs
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table used to find the shortest edit script;
+// larger changes are shown as a single replacement of the changed lines
+const maxDiffCells = 4 << 20
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the unified diff turning a into b, or "" if they are equal.
+// nil stands for a missing file.
+func unifiedDiff(nameA, nameB string, a, b []byte) string {
+	if bytes.Equal(a, b) && (a == nil) == (b == nil) {
+		return ""
+	}
+
+	linesA, linesB := splitLines(a), splitLines(b)
+	ops := diffLines(linesA, linesB)
+
+	if a == nil {
+		nameA = "/dev/null"
+	}
+	if b == nil {
+		nameB = "/dev/null"
+	}
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", nameA, nameB))
+
+	for start := 0; start < len(ops); {
+		// find the next change, and the end of the hunk it starts
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		from := first - diffContext
+		if from < start {
+			from = start
+		}
+
+		to, unchanged := first, 0
+		for to < len(ops) && unchanged <= 2*diffContext {
+			if ops[to].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			to++
+		}
+		if unchanged > diffContext {
+			to -= unchanged - diffContext
+		}
+
+		lineA, lineB := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				lineA++
+			}
+			if op.kind != '-' {
+				lineB++
+			}
+		}
+		countA, countB := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		// empty ranges are numbered after the line they follow
+		if countA == 0 {
+			lineA--
+		}
+		if countB == 0 {
+			lineB--
+		}
+
+		buf.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(lineA, countA), hunkRange(lineB, countB)))
+		for _, op := range ops[from:to] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		start = to
+	}
+
+	return buf.String()
+}
+
+// hunkRange returns the range of a hunk header, its count being left out when it is 1 as diff -u does
+func hunkRange(line, count int) string {
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits data after each '\n', the last line may not end with one
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n') + 1
+		if i == 0 {
+			i = len(data)
+		}
+		lines = append(lines, string(data[:i]))
+		data = data[i:]
+	}
+
+	return lines
+}
+
+// diffLines returns an edit script turning a into b, from the longest common subsequence of their lines
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+
+	// common prefix and suffix do not need to be part of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxDiffCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of midA[i:] and midB[j:]
+		lcs := make([][]int, len(midA)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(midB)+1)
+		}
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				switch {
+				case midA[i] == midB[j]:
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				case lcs[i+1][j] >= lcs[i][j+1]:
+					lcs[i][j] = lcs[i+1][j]
+				default:
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < len(midA) || j < len(midB) {
+			switch {
+			case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+				ops = append(ops, diffOp{' ', midA[i]})
+				i++
+				j++
+			case j == len(midB) || (i < len(midA) && lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', midA[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', midB[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
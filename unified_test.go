@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberLines returns the lines 1 to n, with replacements of some of them
+func numberLines(n int, replacements map[int]string) []byte {
+	var buf strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replacements[i]
+		if !ok {
+			line = fmt.Sprint(i)
+		}
+		buf.WriteString(line + "\n")
+	}
+
+	return []byte(buf.String())
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []byte
+		want string
+	}{
+		{"equal", []byte("1\n2\n"), []byte("1\n2\n"), ""},
+		{"both missing", nil, nil, ""},
+		{
+			"new file", nil, []byte("1\n2\n"),
+			"--- /dev/null\n+++ b\n@@ -0,0 +1,2 @@\n+1\n+2\n",
+		},
+		{
+			"removed file", []byte("1\n2\n"), nil,
+			"--- a\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-1\n-2\n",
+		},
+		{
+			"empty file", []byte{}, []byte("1\n"),
+			"--- a\n+++ b\n@@ -0,0 +1 @@\n+1\n",
+		},
+		{
+			"insertion at the start", []byte("1\n2\n3\n"), []byte("x\n1\n2\n3\n"),
+			"--- a\n+++ b\n@@ -1,3 +1,4 @@\n+x\n 1\n 2\n 3\n",
+		},
+		{
+			"removal at the end", []byte("1\n2\n3\n"), []byte("1\n2\n"),
+			"--- a\n+++ b\n@@ -1,3 +1,2 @@\n 1\n 2\n-3\n",
+		},
+		{
+			// changes 6 unchanged lines apart share a hunk
+			"merged hunks", numberLines(20, nil), numberLines(20, map[int]string{5: "five", 12: "twelve"}),
+			"--- a\n+++ b\n@@ -2,14 +2,14 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n 9\n 10\n 11\n-12\n+twelve\n 13\n 14\n 15\n",
+		},
+		{
+			// changes 7 unchanged lines apart do not
+			"separate hunks", numberLines(20, nil), numberLines(20, map[int]string{5: "five", 13: "thirteen"}),
+			"--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n@@ -10,7 +10,7 @@\n 10\n 11\n 12\n-13\n+thirteen\n 14\n 15\n 16\n",
+		},
+		{
+			"newline added at the end", []byte("1\n2\n3"), []byte("1\n2\n3\n"),
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n 2\n-3\n\\ No newline at end of file\n+3\n",
+		},
+		{
+			"newline removed at the end", []byte("1\n2\n3\n"), []byte("1\n2\n3"),
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n 2\n-3\n+3\n\\ No newline at end of file\n",
+		},
+		{
+			"unchanged last line without newline", []byte("1\n2\n3"), []byte("x\n2\n3"),
+			"--- a\n+++ b\n@@ -1,3 +1,3 @@\n-1\n+x\n 2\n 3\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, test := range tests {
+		if got := unifiedDiff("a", "b", test.a, test.b); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDiffLinesTooLarge(t *testing.T) {
+	// beyond maxDiffCells, the changed lines are replaced as a whole
+	a, b := []string{"same\n"}, []string{"same\n"}
+	for i := 0; i < 3000; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != 6001 || ops[0].kind != ' ' || ops[1].kind != '-' || ops[3000].kind != '-' || ops[3001].kind != '+' {
+		t.Errorf("got %d operations, starting with %q %q", len(ops), ops[0].kind, ops[1].kind)
+	}
+}
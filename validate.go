@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Arman92/go-tl-parser/tlparser"
+)
+
+// runValidate implements 'validate [schema]', printing the problems found in the schema
+// and exiting with status 1 if there are errors
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s validate [schema]\n\nschema is a .tl file, a directory, a URL or - for stdin (the default)\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	location := "-"
+	if flags.NArg() > 0 {
+		location = flags.Arg(0)
+	}
+
+	schema, _ := parseSchema(location)
+
+	failed := false
+	for _, diagnostic := range tlparser.Validate(schema) {
+		fmt.Println(diagnostic)
+		failed = failed || diagnostic.Severity == tlparser.SeverityError
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}